@@ -30,7 +30,8 @@ func main() {
 			client.Prompt(justString)
 		}
 	} else if os.Args[1] == "--interactive" || os.Args[1] == "-i" {
-		client.Interactive()
+		// Extra arguments resume, list or export chat sessions (/internals/interactive.go)
+		client.Interactive(os.Args[2:])
 	} else if os.Args[1] == "--upgrade" || os.Args[1] == "-u" {
 		// The upgrade is triggered here. The upgrade function is in /internals/version.go
 		client.CheckVersion()
@@ -44,6 +45,9 @@ func main() {
 			os.Exit(1)
 		}
 		client.ConfigWrite(os.Args[2], os.Args[3], os.Args[4])
+	} else if os.Args[1] == "--facts" {
+		// Shows the cached device facts, --refresh collects them again (/internals/facts.go)
+		client.Facts(os.Args[2:])
 	} else if os.Args[1] == "--version" || os.Args[1] == "-v" {
 		// Shows the software version
 		client.ShowVersion()
@@ -66,6 +70,27 @@ func main() {
 		justString := strings.Join(argsWithoutProg, " ")
 		client.FeaturePrompt(justString)
 
+	} else if os.Args[1] == "--fleet" && len(os.Args) >= 3 {
+		// Runs show commands and a question against the devices of the inventory (/internals/fleet.go)
+		client.Fleet(os.Args[2:])
+	} else if os.Args[1] == "--history" {
+		// Lists, shows, searches and exports the changes applied from the assistant (/internals/history.go)
+		client.History(os.Args[2:])
+	} else if os.Args[1] == "--rollback" {
+		// Reverts, confirms or lists the changes applied from the assistant (/internals/rollback.go)
+		client.Rollback(os.Args[2:])
+	} else if os.Args[1] == "--approve" {
+		// Signs a change plan, or creates the key of an approver (/internals/plan.go)
+		client.Approve(os.Args[2:])
+	} else if os.Args[1] == "--apply" {
+		// Applies a change plan once approved by another engineer (/internals/plan.go)
+		client.ApplyPlan(os.Args[2:])
+	} else if os.Args[1] == "--template" {
+		// Lists, shows, deletes and applies the configuration templates (/internals/template.go)
+		client.Template(os.Args[2:])
+	} else if os.Args[1] == "--rollback-timer" && len(os.Args) >= 4 {
+		// Started in the background after a change, rolls it back unless confirmed (/internals/cisco/rollback.go)
+		client.RollbackTimer(os.Args[2], os.Args[3])
 	} else if os.Args[1] == "--help" || os.Args[1] == "-h" {
 		// If the app is calledwith --help or -h then client.Help()
 		// is called which shows how the app can be launched (/internals/cli.go)
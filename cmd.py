@@ -1,6 +1,7 @@
 #!/usr/bin/python3
 import argparse
 import cli
+import json
 import re
 
 parser = argparse.ArgumentParser(
@@ -15,13 +16,28 @@ parser.add_argument("-d", action="store_true",
                     dest="device", help="Device info")
 parser.add_argument("-i", action="store_true",
                     dest="inventory", help="Device info")
+parser.add_argument("-f", action="store_true",
+                    dest="facts", help="Version, uptime and license facts")
+parser.add_argument("-s", action="store_true",
+                    dest="stack", help="Stack members info")
 parser.add_argument("-a", type=str, dest="conf", help="Config apply")
+parser.add_argument("-r", type=str, dest="replace",
+                    help="Configure replace with an archived config")
 args = parser.parse_args()
 if args.conf:
-    print(args.conf)
     commands = args.conf.split('%')
     formatted_commands = [command.strip() for command in commands]
-    cli.configurep(formatted_commands)
+    # One result per line, with the parser error of the rejected ones
+    try:
+        results = [{"line": result.command, "ok": result.success,
+                    "error": "" if result.success else (result.output or result.notes or "").strip()}
+                   for result in cli.configure(formatted_commands)]
+    except Exception as error:
+        results = [{"line": "", "ok": False, "error": str(error).strip()}]
+    print(json.dumps(results))
+
+if args.replace:
+    print(cli.cli("configure replace " + args.replace + " force"))
 
 if args.prompt:
     task = ""
@@ -72,3 +88,63 @@ if args.inventory:
         end += pid+","
     end = end[:len(end)-1]
     print(end)
+
+if args.stack:
+    members = {}
+
+    # Role of each member. Standalone devices and routers have no "show switch".
+    try:
+        data = cli.cli("show switch")
+    except Exception:
+        data = ""
+    for match in re.finditer(r'^\*?\s*(\d+)\s+(Active|Standby|Member)\s', data, re.MULTILINE):
+        members[int(match.group(1))] = {
+            "member": int(match.group(1)), "role": match.group(2)}
+
+    # Model and software version of each member
+    data = cli.cli("show version")
+    for match in re.finditer(r'^\*?\s+(\d+)\s+\d+\s+(\S+)\s+(\S+)\s+\S+\s+\S+\s*$', data, re.MULTILINE):
+        member = members.setdefault(int(match.group(1)), {
+            "member": int(match.group(1)), "role": ""})
+        member["pid"] = match.group(2)
+        member["version"] = match.group(3)
+
+    # Serial number of each member and the modules installed in it.
+    # Optics are named after their interface, e.g. "TenGigabitEthernet2/1/1" is in member 2.
+    data = cli.cli("show inventory")
+    pattern = r'NAME: "([^"]*)".*?\n\s*PID: ([^,]*?)\s*,.*?SN: ?(\S*)'
+    for name, pid, sn in re.findall(pattern, data):
+        if not pid:
+            continue
+        chassis = False
+        match = re.match(r'(?:Switch|Chassis) (\d+)(.*)$', name)
+        if match:
+            number = int(match.group(1))
+            chassis = match.group(2).strip() == ""
+        else:
+            match = re.match(r'[A-Za-z-]+(\d+)/', name)
+            number = int(match.group(1)) if match else 1
+        member = members.setdefault(number, {"member": number, "role": ""})
+        if chassis or "sn" not in member:
+            member.setdefault("pid", pid)
+            member["sn"] = sn
+        else:
+            member.setdefault("modules", []).append(pid)
+
+    print(json.dumps([members[n] for n in sorted(members)]))
+
+if args.facts:
+    facts = {"version": "", "uptime": "", "license_level": ""}
+    output = cli.cli("show version")
+    match = re.search(r"Cisco IOS XE Software, Version\s+(\S+)", output)
+    if match:
+        facts["version"] = match.group(1)
+    match = re.search(r"uptime is (.+)", output)
+    if match:
+        facts["uptime"] = match.group(1).strip()
+    # Switches report "network-advantage", routers the technology package level
+    match = re.search(r"^\S+\s+(network-\S+|\S+k9)\s+", output, re.MULTILINE) or \
+        re.search(r"License Level:\s*(\S+)", output)
+    if match:
+        facts["license_level"] = match.group(1)
+    print(json.dumps(facts))
@@ -1,14 +1,24 @@
 package cisco
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
 type IOSXE struct {
 }
 
+// CommandOutput is the result of one of the commands ran by Commands.
+type CommandOutput struct {
+	Command string
+	Output  string
+	Err     error
+}
+
 // Interactiom between app and python script cmd.py is handled here.
 func (c *IOSXE) Device() (string, string, string, string, error) {
 	cmd := exec.Command("python3", "cmd.py", "-d")
@@ -20,6 +30,65 @@ func (c *IOSXE) Device() (string, string, string, string, error) {
 	return data[0], data[1], data[2], strings.Trim(data[3], "\n"), nil
 }
 
+// StackMember holds the facts of one StackWise member.
+// A standalone device is reported as a single member.
+type StackMember struct {
+	Member  int      `json:"member"`
+	Role    string   `json:"role"`
+	PID     string   `json:"pid"`
+	SN      string   `json:"sn"`
+	Version string   `json:"version"`
+	Modules []string `json:"modules,omitempty"`
+}
+
+// Stack returns the facts of every member of the stack
+func (c *IOSXE) Stack() ([]StackMember, error) {
+	cmd := exec.Command("python3", "cmd.py", "-s")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New("Missing/Corrupted dependency")
+	}
+	var members []StackMember
+	if err := json.Unmarshal(out, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// VersionFacts are the facts of the device that change over time
+type VersionFacts struct {
+	Version      string `json:"version"`
+	Uptime       string `json:"uptime"`
+	LicenseLevel string `json:"license_level"`
+}
+
+// Version returns the running software version, uptime and license level
+func (c *IOSXE) Version() (VersionFacts, error) {
+	facts := VersionFacts{}
+	cmd := exec.Command("python3", "cmd.py", "-f")
+	out, err := cmd.Output()
+	if err != nil {
+		return facts, errors.New("Missing/Corrupted dependency")
+	}
+	err = json.Unmarshal(out, &facts)
+	return facts, err
+}
+
+// Configure applies the configuration lines and returns the result of each line,
+// with the parser error of the lines IOS rejected
+func (c *IOSXE) Configure(lines []string) ([]LineResult, error) {
+	cmd := exec.Command("python3", "cmd.py", "-a", strings.Join(lines, "%"))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New("Missing/Corrupted dependency")
+	}
+	var results []LineResult
+	if err := json.Unmarshal(out, &results); err != nil {
+		return nil, fmt.Errorf("unexpected result from cmd.py: %s", strings.TrimSpace(string(out)))
+	}
+	return results, nil
+}
+
 func (c *IOSXE) Inventory() (string, error) {
 	cmd := exec.Command("python3", "cmd.py", "-i")
 	out, err := cmd.Output()
@@ -37,3 +106,25 @@ func (c *IOSXE) Command(command string) (string, error) {
 	}
 	return string(out), nil
 }
+
+// Commands runs the given commands in parallel on the device.
+// Results are returned in the same order as the commands.
+func (c *IOSXE) Commands(commands []string) []CommandOutput {
+	results := make([]CommandOutput, len(commands))
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			out, err := c.Command(command)
+			results[i] = CommandOutput{Command: command, Output: out, Err: err}
+		}(i, command)
+	}
+	wg.Wait()
+	return results
+}
+
+// LabelOutput marks a command output so the model knows which command produced it.
+func LabelOutput(command, output string) string {
+	return fmt.Sprintf("=== Output of '%s' ===\n%s\n", command, output)
+}
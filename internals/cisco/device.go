@@ -1,19 +1,32 @@
 package cisco
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
-
+	"net"
 	"os/exec"
-	"reflect"
 	"strings"
 
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
 	"github.com/chzyer/readline"
-	"github.com/google/generative-ai-go/genai"
-	"github.com/sashabaranov/go-openai"
 )
 
 var CodeBlocks []string
+
+// OnApply is called with the configuration applied by ReviewConfig
+var OnApply func(config string)
+
+// FixRequested is set by ReviewConfig when the user wants the assistant to
+// correct the lines IOS rejected
+var FixRequested bool
+
+// RequireApproval makes ReviewConfig save the configuration as a change plan
+// instead of applying it, a second engineer has to approve the plan
+var RequireApproval bool
+
+// AutoApprove makes ReviewConfig apply the configuration without the editor
+// and the confirmation, for the batch chat run with --allow-apply
+var AutoApprove bool
 var (
 	Rl *readline.Instance
 )
@@ -31,177 +44,12 @@ var Cyan = "\033[36m"
 var Gray = "\033[37m"
 var White = "\033[97m"
 
-/////////////////////////////////////////
-// THIS SECTION IS FOR TOOL DEFINITION //
-/////////////////////////////////////////
-
-// OpenAI tools
-var F1_openai = openai.FunctionDefinition{
-	Name:        "Show_cdp",
-	Description: "Get information about what devices are connected to this device. Has information about neighbouring devices.",
-}
-var Show_cdp_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F1_openai,
-}
-
-var F2_openai = openai.FunctionDefinition{
-	Name:        "Show_ip_route",
-	Description: "Get information about what IPv4/IPv6 routes are defined. Routes from EIGRP, OSPF, Static routes and default gateway and many others.",
-}
-var Show_ip_route_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F2_openai,
-}
-var F3_openai = openai.FunctionDefinition{
-	Name:        "Show_ip_int_br",
-	Description: "Get a summary of the status of the interfaces. It gives info about the status of the interface, ip address and others.",
-}
-
-var Show_ip_int_br_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F3_openai,
-}
-var F4_openai = openai.FunctionDefinition{
-	Name:        "Show_vlan",
-	Description: "Tells what vlans are configured on the device, their name and on which interfaces are applied",
-}
-
-var Show_vlan_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F4_openai,
-}
-var F5_openai = openai.FunctionDefinition{
-	Name:        "Show_stp",
-	Description: "Tells information about Spanning Tree Protocol or STP. How it is configured and other details.",
-}
-
-var Show_stp_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F5_openai,
-}
-var F6_openai = openai.FunctionDefinition{
-	Name:        "Show_mac_address",
-	Description: "Tells what mac addresses are seen by each port of the device and other information. This is the mac address table of the device",
-}
-var Show_mac_address_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F6_openai,
-}
-
-var F7_openai = openai.FunctionDefinition{
-	Name:        "Show_arp",
-	Description: "Tells information about MAC address and IP bindings and on which interface is present",
-}
-
-var Show_arp_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F7_openai,
-}
-
-var F8_openai = openai.FunctionDefinition{
-	Name:        "ReviewConfig",
-	Description: "This function start the process to apply configuration or commands to the device. Also helps to review the commands in order to apply them",
-}
-
-var Review_config_tool_openai = openai.Tool{
-	Type:     openai.ToolTypeFunction,
-	Function: F8_openai,
-}
-
-// Gemini tools
-var F1_gemini = genai.FunctionDeclaration{
-	Name:        "Show_cdp",
-	Description: "Get information about what devices are connected to this device. Has information about neighbouring devices.",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}
-
-var F2_gemini = genai.FunctionDeclaration{
-	Name:        "Show_ip_route",
-	Description: "Get information about what IPv4/IPv6 routes are defined. Routes from EIGRP, OSPF, Static routes and default gateway and many others.",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}
-
-var F3_gemini = genai.FunctionDeclaration{
-	Name:        "Show_ip_int_br",
-	Description: "Get a summary of the status of the interfaces. It gives info about the status of the interface, ip address and others.",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}		
-
-var F4_gemini = genai.FunctionDeclaration{
-	Name:        "Show_vlan",
-	Description: "Tells what vlans are configured on the device, their name and on which interfaces are applied",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}		
-
-var F5_gemini = genai.FunctionDeclaration{
-	Name:        "Show_stp",
-	Description: "Tells information about Spanning Tree Protocol or STP. How it is configured and other details.",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}
-
-var F6_gemini = genai.FunctionDeclaration{
-	Name:        "Show_mac_address",
-	Description: "Tells what mac addresses are seen by each port of the device and other information. This is the mac address table of the device",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}
-
-var F7_gemini = genai.FunctionDeclaration{
-	Name:        "Show_arp",
-	Description: "Tells information about MAC address and IP bindings and on which interface is present",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}	
-
-var F8_gemini = genai.FunctionDeclaration{
-	Name:        "ReviewConfig",
-	Description: "This function start the process to apply configuration or commands to the device. Also helps to review the commands in order to apply them",
-	Parameters: &genai.Schema{
-		Type:       genai.TypeObject,
-		Properties: map[string]*genai.Schema{},
-	},
-}
-
-var func_declarations_gemini = []*genai.FunctionDeclaration{
-	&F1_gemini,
-	&F2_gemini,
-	&F3_gemini,
-	&F4_gemini,
-	&F5_gemini,
-	&F6_gemini,
-	&F7_gemini,
-	&F8_gemini,
-}
-
-var Tools_gemini = genai.Tool{
-	FunctionDeclarations: func_declarations_gemini,
-}
-
 //////////////////////////////////////////////////////////////
 // THIS SECTION IS FOR FUNCTIONS TO INTERACT WITH THE DEVICE//
 //////////////////////////////////////////////////////////////
 
 func ReviewConfig() string {
+	FixRequested = false
 
 	if len(CodeBlocks) > 0 {
 		var contentLines []string
@@ -214,52 +62,270 @@ func ReviewConfig() string {
 			}
 		}
 		allCode := strings.Join(contentLines, "\n")
-		editedContent := multiLineEdit(Rl, allCode)
+		editedContent := allCode
+		if !AutoApprove {
+			editedContent = editConfig(allCode)
+		}
 
-		fmt.Println("\nEdited content:")
-		fmt.Println(Green + editedContent + Reset)
-		fmt.Println("\nDo you want to apply these changes? (yes/no)")
-		confirmation, _ := Rl.Readline()
-		if strings.ToLower(strings.TrimSpace(confirmation)) == "yes" {
-			CodeBlocks = []string{editedContent}
-			//HERE I APPLY THE CONFIG ON THE SWITCH
-			singleLineContent := strings.ReplaceAll(editedContent, "\n", "%")
-			cmd := exec.Command("python3", "cmd.py", "-a", singleLineContent)
-			_, err := cmd.Output()
-			if err != nil {
-				return ""
+		// Secrets were redacted before reaching the LLM and are never put back automatically
+		for len(redact.Placeholders(editedContent)) > 0 {
+			fmt.Println(Red + "\nThese lines contain redacted values. Replace them with the real values before applying:" + Reset)
+			for _, line := range redact.Placeholders(editedContent) {
+				fmt.Println(Red + "    " + line + Reset)
 			}
-			fmt.Println("Changes saved.")
-			cmd = exec.Command("python3", "cmd.py", "-c", "show clock")
-			out, err := cmd.Output()
-			if err != nil {
-				return ""
+			if AutoApprove || !confirm("Do you want to edit the configuration again?") {
+				fmt.Println("Changes discarded.")
+				return "The configuration contains redacted placeholders and was not applied. The user has to type the real values."
 			}
-			timestamp := string(out)
+			editedContent = editConfig(editedContent)
+		}
 
-			// Prepare the log entry
-			logEntry := fmt.Sprintf("Timestamp: %s\nEdited Content:\n%s\n\n", timestamp, editedContent)
+		editedContent = reviewDiff(editedContent)
+		if strings.TrimSpace(editedContent) == "" {
+			fmt.Println("Nothing to apply.")
+			return "The configuration is already present on the device, nothing was applied."
+		}
 
-			// Open the file in append mode (or create it if it doesn't exist)
-			file, err := os.OpenFile("configs.history", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				fmt.Println("Error opening file:", err)
-				return ""
-			}
-			defer file.Close()
+		findings := reviewLint(editedContent)
+		if AutoApprove && LintErrors(findings) > 0 {
+			fmt.Println("Changes discarded.")
+			return "The configuration was not applied, the linter found errors:\n" + lintReport(findings)
+		}
 
-			// Write the log entry to the file
-			_, err = file.WriteString(logEntry)
+		risks := reviewRisks(editedContent)
+		if blocked := risksAbove(risks, RiskBlockScore); len(blocked) > 0 {
+			fmt.Println("Changes discarded, the risk policy blocks these lines.")
+			return "The configuration was not applied, the risk policy blocks these lines:\n" + riskReport(blocked)
+		}
+		risky := risksAbove(risks, RiskConfirmScore)
+		if AutoApprove && len(risky) > 0 && !RequireApproval {
+			fmt.Println("Changes discarded, the risky lines have to be confirmed by a user.")
+			return "The configuration was not applied, these lines have to be confirmed by a user:\n" + riskReport(risky)
+		}
+
+		fmt.Println("\nEdited content:")
+		fmt.Println(Green + editedContent + Reset)
+		choice := "yes"
+		switch {
+		case RequireApproval:
+			choice = "no"
+			if AutoApprove || confirm("\nSave these changes as a plan for a second engineer to approve?") {
+				choice = "plan"
+			}
+		case !AutoApprove:
+			choice = choose("\nDo you want to apply these changes, or save them as a plan for approval?", "yes", "no", "plan")
+		}
+		switch choice {
+		case "plan":
+			plan, err := NewPlan(allCode, editedContent, risks, findings)
+			if err == nil {
+				err = SavePlan(plan)
+			}
 			if err != nil {
-				fmt.Println("Error writing to file:", err)
-				return ""
+				fmt.Println(Red+"Could not save the plan:", err, Reset)
+				return "Saving the change plan failed, nothing was applied."
 			}
-
-		} else {
+			fmt.Printf("Plan saved to %s. A second engineer approves it with aixedge-approve, then apply it with aixedge-apply.\n", PlanPath(plan.ID))
+			return fmt.Sprintf("The configuration was saved as change plan %s for approval, it is not applied yet.", plan.ID)
+		case "yes":
+			if !confirmRisks(risky) {
+				fmt.Println("Changes discarded.")
+				return "The user did not confirm these risky lines, nothing was applied:\n" + riskReport(risky)
+			}
+			CodeBlocks = []string{editedContent}
+			return applyChange(allCode, editedContent)
+		default:
 			fmt.Println("Changes discarded.")
+			if len(findings) > 0 {
+				return "The user discarded the changes. The linter reported:\n" + lintReport(findings)
+			}
+			return "The user discarded the changes."
 		}
+	}
+	fmt.Println("No code blocks to edit.")
+	return "There is no configuration to review. Suggest the commands in code blocks first."
+}
+
+// applyChange archives the running-config, applies the configuration, records the
+// change, verifies it and waits for its confirmation
+func applyChange(suggested string, editedContent string) string {
+	// The running-config is archived first so the change can be rolled back
+	changeID := NewChangeID()
+	archived := true
+	if err := ArchiveRunningConfig(changeID); err != nil {
+		archived = false
+		fmt.Println(Red+"Could not archive the running-config, the change could not be rolled back:", err, Reset)
+		if AutoApprove || !confirm("Apply the changes anyway?") {
+			fmt.Println("Changes discarded.")
+			return "The user discarded the changes."
+		}
+	}
+	//HERE I APPLY THE CONFIG ON THE SWITCH
+	var lines []string
+	for _, line := range strings.Split(editedContent, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	iosxe := IOSXE{}
+	results, err := iosxe.Configure(lines)
+	failed := failedLines(results)
+
+	record := newChangeRecord(changeID, suggested, editedContent)
+	record.Lines = results
+	record.Applied = err == nil && len(failed) < len(results)
+	if err := RecordChange(record); err != nil {
+		fmt.Println("Error writing the change history:", err)
+	}
+
+	if err != nil {
+		fmt.Println(Red+"Applying the configuration failed:", err, Reset)
+		return "Applying the configuration failed."
+	}
+	if len(failed) > 0 {
+		fmt.Printf(Red+"\nIOS rejected %d of %d lines:\n"+Reset, len(failed), len(results))
+		for _, result := range failed {
+			fmt.Println(Red + "  " + result.Line + Reset)
+			fmt.Println(Gray + "    " + strings.ReplaceAll(result.Error, "\n", "\n    ") + Reset)
+		}
+	}
+	if !record.Applied {
+		return "IOS rejected every line, nothing was applied:\n" + failedReport(failed) + offerFix()
+	}
+
+	if len(failed) > 0 {
+		fmt.Println("Changes partly saved.")
 	} else {
-		fmt.Println("No code blocks to edit.")
+		fmt.Println("Changes saved.")
+	}
+	applied := fmt.Sprintf("The user applied this configuration (change %s):\n%s", changeID, editedContent)
+	if len(failed) > 0 {
+		applied += "\nIOS rejected these lines:\n" + failedReport(failed)
+	}
+	if OnApply != nil {
+		OnApply(editedContent)
+	}
+	verify := func() {
+		verifyChange(&record)
+		applied += fmt.Sprintf("\nVerification: %s. %s", record.Verification.Verdict, record.Verification.Summary)
+		if record.Verification.FollowUp != "" {
+			applied += "\nSuggested follow-up: " + record.Verification.FollowUp
+		}
+	}
+	if !archived {
+		verify()
+	} else if confirmApplied(changeID, verify) == "rolled back" {
+		return "The user applied this configuration, then rolled it back:\n" + editedContent
+	}
+	if len(failed) > 0 {
+		applied += offerFix()
+	}
+	return applied
+}
+
+func failedLines(results []LineResult) []LineResult {
+	var failed []LineResult
+	for _, result := range results {
+		if !result.OK {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// failedReport lists the rejected lines with the parser error for the assistant
+func failedReport(failed []LineResult) string {
+	var report []string
+	for _, result := range failed {
+		report = append(report, fmt.Sprintf("%s => %s", result.Line, strings.Join(strings.Fields(result.Error), " ")))
+	}
+	return strings.Join(report, "\n")
+}
+
+// Function asks the user whether the assistant should correct the rejected lines
+func offerFix() string {
+	if AutoApprove || !confirm("Ask the assistant to fix the failed lines?") {
+		return ""
+	}
+	FixRequested = true
+	return "\nThe user asks you to fix the rejected lines: explain the parser errors and suggest corrected commands for these lines only."
+}
+
+// Function shows what the configuration changes compared with the running-config
+// and lets the user leave out the lines that are already configured
+func reviewDiff(config string) string {
+	running, err := RunningConfig()
+	if err != nil {
+		fmt.Println("Could not read the running-config, no diff available.")
+		return config
+	}
+	diff := DiffConfig(ParseProposedConfig(config), ParseConfig(running))
+	fmt.Println("\nChanges compared with the running-config:")
+	PrintDiff(diff)
+	noop := CountNoop(diff)
+	if noop == 0 || AutoApprove {
+		return config
+	}
+	if confirm(fmt.Sprintf("\nSkip the %d lines that change nothing?", noop)) {
+		return RenderDiff(diff, true)
+	}
+	return config
+}
+
+// Function shows the problems the linter finds in the configuration
+func reviewLint(config string) []LintFinding {
+	interfaces, err := DeviceInterfaces()
+	if err != nil {
+		fmt.Println("Could not read the interfaces of the device, their names are not checked.")
+	}
+	findings := LintConfig(config, interfaces)
+	if len(findings) > 0 {
+		fmt.Printf("\nThe linter found %d errors and %d warnings:\n", LintErrors(findings), len(findings)-LintErrors(findings))
+		PrintLint(findings)
+	}
+	return findings
+}
+
+// Function shows the lines that may disrupt the network, judged with the state of the device
+func reviewRisks(config string) []Risk {
+	state := LoadDeviceState()
+	if len(state.Unreadable) > 0 {
+		fmt.Printf(Yellow+"\nCould not read %s, every shutdown and address change of an interface is treated as risky.\n"+Reset,
+			strings.Join(state.Unreadable, ", "))
+	}
+	risks := AssessRisk(config, state)
+	if len(risks) > 0 {
+		fmt.Println("\nRisk assessment:")
+		PrintRisks(risks)
+	}
+	return risks
+}
+
+// Function makes the user type each risky line again, a yes is not enough for them
+func confirmRisks(risks []Risk) bool {
+	for _, risk := range risks {
+		fmt.Printf(Yellow+"\n%s\n    %s\n"+Reset, risk.Line, risk.Reason)
+		fmt.Println("Type the line again to apply it:")
+		answer, err := Rl.Readline()
+		if err != nil || !strings.EqualFold(strings.Join(strings.Fields(answer), " "), strings.Join(strings.Fields(risk.Line), " ")) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function asks a yes/no question to the user
+func confirm(question string) bool {
+	return choose(question, "yes", "no") == "yes"
+}
+
+// Function asks the user to pick one of the options, any other answer returns ""
+func choose(question string, options ...string) string {
+	fmt.Println(question + " (" + strings.Join(options, "/") + ")")
+	answer, _ := Rl.Readline()
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if isInList(options, answer) {
+		return answer
 	}
 	return ""
 }
@@ -295,111 +361,97 @@ func multiLineEdit(rl *readline.Instance, originalContent string) string {
 	return strings.Join(editedLines, "\n")
 }
 
-func CallFunctionByName(functionName string, args ...interface{}) (interface{}, error) {
-	functions := map[string]interface{}{
-		"Show_cdp":         Show_cdp,
-		"Show_ip_route":    Show_ip_route,
-		"Show_ip_int_br":   Show_ip_int_br,
-		"Show_vlan":        Show_vlan,
-		"Show_stp":         Show_stp,
-		"Show_mac_address": Show_mac_address,
-		// "show_runn_interface": show_runn_interface,
-		"Show_arp":     Show_arp,
-		"ReviewConfig": ReviewConfig,
-	}
-
-	if fn, ok := functions[functionName]; ok {
-		fnValue := reflect.ValueOf(fn)
-		fnType := fnValue.Type()
-
-		if fnType.Kind() != reflect.Func {
-			return nil, fmt.Errorf("'%s' is not a function", functionName)
-		}
-
-		// Check if the number of provided arguments matches the function's arity
-		if len(args) != fnType.NumIn() {
-			return nil, fmt.Errorf("invalid number of arguments for function '%s': expected %d, got %d", functionName, fnType.NumIn(), len(args))
+// deviceData returns structured data from StructuredBackend when one is configured,
+// otherwise (or if it fails) the output of the CLI command.
+func deviceData(kind string, command string) string {
+	if StructuredBackend != nil {
+		if data, err := StructuredBackend.Data(kind); err == nil {
+			return data
 		}
-
-		// Convert the provided arguments to reflect.Value
-		in := make([]reflect.Value, len(args))
-		for i, arg := range args {
-			in[i] = reflect.ValueOf(arg)
-		}
-
-		// Call the function
-		out := fnValue.Call(in)
-
-		// Return the result if there's any
-		if len(out) > 0 {
-			return out[0].Interface(), nil
-		}
-		return nil, nil
 	}
-
-	return nil, fmt.Errorf("function '%s' not found", functionName)
-}
-
-func Show_cdp() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show cdp neighbour detail")
+	cmd := exec.Command("python3", "cmd.py", "-c", command)
 	out, err := cmd.Output()
 	if err != nil {
 		return ""
 	}
 	return string(out)
+}
 
+func Show_cdp() string {
+	return deviceData("cdp", "show cdp neighbors detail")
 }
 
 func Show_ip_route() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show ip route")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(out)
+	return deviceData("routes", "show ip route")
 }
 
 func Show_ip_int_br() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show ip interface brief")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(out)
+	return deviceData("interfaces", "show ip interface brief")
 }
 
 func Show_vlan() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show vlan")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(out)
+	return deviceData("vlans", "show vlan")
 }
 
 func Show_stp() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show spanning-tree")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(out)
+	return deviceData("stp", "show spanning-tree")
 }
 
 func Show_mac_address() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show mac-address-table")
-	out, err := cmd.Output()
+	return deviceData("mac", "show mac-address-table")
+}
+
+func Show_arp() string {
+	return deviceData("arp", "show arp")
+}
+
+func Show_stack() string {
+	iosxe := IOSXE{}
+	members, err := iosxe.Stack()
+	if err != nil {
+		return ""
+	}
+	out, err := json.MarshalIndent(members, "", "  ")
 	if err != nil {
 		return ""
 	}
 	return string(out)
 }
 
-func Show_arp() string {
-	cmd := exec.Command("python3", "cmd.py", "-c", "show arp")
-	out, err := cmd.Output()
+// runShow runs a show command allowed by the command policy
+func runShow(command string) string {
+	if !IsValidShowCommand(command) {
+		return fmt.Sprintf("The command '%s' is not allowed.", command)
+	}
+	iosxe := IOSXE{}
+	out, err := iosxe.Command(command)
 	if err != nil {
-		return ""
+		return fmt.Sprintf("The command '%s' failed on the device.", command)
 	}
-	return string(out)
+	return out
+}
+
+func Run_show_command(command string) string {
+	return runShow(strings.Join(strings.Fields(command), " "))
+}
+
+func Show_interface(name string) string {
+	return runShow("show interfaces " + strings.ReplaceAll(name, " ", ""))
+}
+
+func Show_vlan_id(vlan string) string {
+	return runShow("show vlan id " + vlan)
+}
+
+func Show_ip_route_prefix(prefix string) string {
+	if ip, network, err := net.ParseCIDR(prefix); err == nil {
+		if ip.To4() == nil {
+			return runShow("show ipv6 route " + network.String())
+		}
+		return runShow(fmt.Sprintf("show ip route %s %s", network.IP, net.IP(network.Mask)))
+	}
+	if ip := net.ParseIP(prefix); ip != nil && ip.To4() == nil {
+		return runShow("show ipv6 route " + ip.String())
+	}
+	return runShow("show ip route " + prefix)
 }
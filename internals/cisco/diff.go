@@ -0,0 +1,213 @@
+package cisco
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DiffStatus int
+
+const (
+	// DiffContext is a sub-mode already configured, with changes below it
+	DiffContext DiffStatus = iota
+	// DiffNew is not in the running-config
+	DiffNew
+	// DiffChanged replaces a value of the running-config, e.g. another description
+	DiffChanged
+	// DiffPresent is already in the running-config
+	DiffPresent
+	// DiffRemoved is a "no" command removing a line of the running-config
+	DiffRemoved
+	// DiffAbsent is a "no" command for a line not in the running-config
+	DiffAbsent
+)
+
+// DiffLine is a proposed line compared with the running-config
+type DiffLine struct {
+	Text   string
+	Status DiffStatus
+	// The running-config line replaced or removed
+	Was      string
+	Children []*DiffLine
+}
+
+// Commands holding a single value, a new value replaces the configured one
+var valueCommands = []string{
+	"description", "hostname", "ip address", "switchport access vlan", "switchport voice vlan",
+	"switchport trunk native vlan", "switchport mode", "speed", "duplex", "mtu", "name",
+	"ip default-gateway", "bandwidth", "ip domain name", "ip domain-name", "spanning-tree portfast",
+	"channel-group", "power inline", "ip helper-address", "vrf forwarding", "service-policy input", "service-policy output",
+}
+
+// DiffConfig compares the proposed lines with the running-config, level by level
+func DiffConfig(proposed []*ConfigLine, running []*ConfigLine) []*DiffLine {
+	var diff []*DiffLine
+	for _, line := range proposed {
+		diff = append(diff, diffLine(line, running))
+	}
+	return diff
+}
+
+func diffLine(line *ConfigLine, running []*ConfigLine) *DiffLine {
+	d := &DiffLine{Text: line.Text}
+	if target, ok := strings.CutPrefix(normalize(line.Text), "no "); ok {
+		if existing := findRemoved(target, running); existing != nil {
+			d.Status = DiffRemoved
+			d.Was = existing.Text
+		} else {
+			d.Status = DiffAbsent
+		}
+		return d
+	}
+
+	existing := findLine(line.Text, running)
+	if existing == nil {
+		d.Status = DiffNew
+		if changed := findChanged(line.Text, running); changed != nil {
+			d.Status = DiffChanged
+			d.Was = changed.Text
+		}
+		for _, child := range line.Children {
+			d.Children = append(d.Children, newLine(child))
+		}
+		return d
+	}
+
+	d.Status = DiffPresent
+	for _, child := range line.Children {
+		childDiff := diffLine(child, existing.Children)
+		if !childDiff.noop() {
+			d.Status = DiffContext
+		}
+		d.Children = append(d.Children, childDiff)
+	}
+	return d
+}
+
+// Everything below a new sub-mode is new, "no shutdown" included
+func newLine(line *ConfigLine) *DiffLine {
+	d := &DiffLine{Text: line.Text, Status: DiffNew}
+	for _, child := range line.Children {
+		d.Children = append(d.Children, newLine(child))
+	}
+	return d
+}
+
+func findLine(text string, running []*ConfigLine) *ConfigLine {
+	text = normalize(text)
+	for _, line := range running {
+		if normalize(line.Text) == text {
+			return line
+		}
+	}
+	return nil
+}
+
+// "no description" removes "description Uplink", "no shutdown" removes "shutdown"
+func findRemoved(target string, running []*ConfigLine) *ConfigLine {
+	for _, line := range running {
+		text := normalize(line.Text)
+		if text == target || strings.HasPrefix(text, target+" ") {
+			return line
+		}
+	}
+	return nil
+}
+
+func findChanged(text string, running []*ConfigLine) *ConfigLine {
+	text = normalize(text)
+	for _, command := range valueCommands {
+		if !strings.HasPrefix(text, command+" ") {
+			continue
+		}
+		for _, line := range running {
+			if strings.HasPrefix(normalize(line.Text), command+" ") {
+				return line
+			}
+		}
+	}
+	return nil
+}
+
+// noop tells whether applying the line changes nothing
+func (d *DiffLine) noop() bool {
+	return d.Status == DiffPresent || d.Status == DiffAbsent
+}
+
+// CountNoop returns the number of proposed lines that change nothing
+func CountNoop(diff []*DiffLine) int {
+	count := 0
+	for _, d := range diff {
+		if d.noop() {
+			count++
+		}
+		if d.Status == DiffContext || d.Status == DiffPresent {
+			count += CountNoop(d.Children)
+		}
+	}
+	return count
+}
+
+// PrintDiff shows the diff colourised:
+// + new, ~ changed, - removed, = already in the running-config
+func PrintDiff(diff []*DiffLine) {
+	fmt.Print(FormatDiff(diff, true))
+}
+
+// FormatDiff writes the diff with its markers, colourised or as plain text
+func FormatDiff(diff []*DiffLine, colour bool) string {
+	paint := func(code string) string {
+		if colour {
+			return code
+		}
+		return ""
+	}
+	var out strings.Builder
+	var show func(diff []*DiffLine, depth int)
+	show = func(diff []*DiffLine, depth int) {
+		for _, d := range diff {
+			indent := strings.Repeat(" ", depth)
+			switch d.Status {
+			case DiffContext:
+				out.WriteString("  " + indent + d.Text)
+			case DiffNew:
+				out.WriteString(paint(Green) + "+ " + indent + d.Text + paint(Reset))
+			case DiffChanged:
+				out.WriteString(paint(Yellow) + "~ " + indent + d.Text + paint(Gray) + "   (was: " + d.Was + ")" + paint(Reset))
+			case DiffRemoved:
+				out.WriteString(paint(Red) + "- " + indent + d.Text + paint(Gray) + "   (removes: " + d.Was + ")" + paint(Reset))
+			case DiffPresent:
+				out.WriteString(paint(Gray) + "= " + indent + d.Text + "   (already configured)" + paint(Reset))
+			case DiffAbsent:
+				out.WriteString(paint(Gray) + "= " + indent + d.Text + "   (not configured)" + paint(Reset))
+			}
+			out.WriteString("\n")
+			show(d.Children, depth+1)
+		}
+	}
+	show(diff, 0)
+	return out.String()
+}
+
+// RenderDiff writes the proposed configuration back. With skipNoop the lines
+// that change nothing are left out, sub-modes are kept for their changed lines.
+// cmd.py sends the lines without their indentation, so every sub-mode ends with
+// an explicit "exit" and the next line is entered in the mode it was written for.
+func RenderDiff(diff []*DiffLine, skipNoop bool) string {
+	var out strings.Builder
+	var render func(diff []*DiffLine, depth int)
+	render = func(diff []*DiffLine, depth int) {
+		for _, d := range diff {
+			if skipNoop && d.noop() {
+				continue
+			}
+			out.WriteString(strings.Repeat(" ", depth) + d.Text + "\n")
+			render(d.Children, depth+1)
+			if len(d.Children) > 0 || IsSubMode(d.Text) {
+				out.WriteString(strings.Repeat(" ", depth+1) + "exit\n")
+			}
+		}
+	}
+	render(diff, 0)
+	return out.String()
+}
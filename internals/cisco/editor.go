@@ -0,0 +1,204 @@
+package cisco
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// Editor used when $EDITOR is not set, the one available on the guestshell
+const defaultEditor = "vi"
+
+// editConfig lets the user pick how to review the proposed configuration:
+// line by line, in $EDITOR or in the built-in buffer editor
+func editConfig(content string) string {
+	editor := strings.TrimSpace(os.Getenv("EDITOR"))
+	if editor == "" {
+		editor = defaultEditor
+	}
+	switch choose(fmt.Sprintf("\nReview the configuration line by line, in %s (editor) or in the built-in editor (buffer)? Enter for line by line.", editor), "line", "editor", "buffer") {
+	case "editor":
+		edited, err := externalEdit(editor, content)
+		if err != nil {
+			fmt.Println(Red+"Could not run the editor:", err, Reset)
+			return multiLineEdit(Rl, content)
+		}
+		return edited
+	case "buffer":
+		return bufferEdit(Rl, content)
+	default:
+		return multiLineEdit(Rl, content)
+	}
+}
+
+const editorHeader = "! Edit the configuration to apply, save and quit the editor.\n" +
+	"! Lines starting with ! are ignored, an empty file applies nothing.\n"
+
+// externalEdit opens the configuration in the editor of the user and returns the saved file
+func externalEdit(editor string, content string) (string, error) {
+	file, err := os.CreateTemp("", "aixedge-*.cfg")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(editorHeader + content + "\n")
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{defaultEditor}
+	}
+	cmd := exec.Command(args[0], append(args[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "!") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+const bufferHelp = "Commands: <n> edit line n | i <n> insert before line n | a append | d <n>[-<m>] delete | " +
+	"m <n> <to> move | u undo | w save | q discard the edits"
+
+// bufferEdit is a full-screen editor of the configuration with insert, delete, move and undo
+func bufferEdit(rl *readline.Instance, content string) string {
+	buffer := strings.Split(content, "\n")
+	var undo [][]string
+	save := func() {
+		undo = append(undo, append([]string(nil), buffer...))
+	}
+	// Lines typed until a single "." are inserted at the position
+	insert := func(at int) {
+		var added []string
+		for {
+			rl.SetPrompt(fmt.Sprintf("%d+ ", at+len(added)+1))
+			line, err := rl.Readline()
+			if err != nil || strings.TrimSpace(line) == "." {
+				break
+			}
+			added = append(added, line)
+		}
+		if len(added) > 0 {
+			save()
+			buffer = append(buffer[:at], append(added, buffer[at:]...)...)
+		}
+	}
+	// Line numbers typed by the user start at 1
+	lineNumber := func(text string, last int) (int, bool) {
+		n, err := strconv.Atoi(text)
+		return n - 1, err == nil && n >= 1 && n <= last
+	}
+
+	rl.HistoryDisable()
+	defer rl.HistoryEnable()
+	defer rl.SetPrompt("> ")
+	message := ""
+	for {
+		// Full-screen: the buffer is drawn again after every command
+		fmt.Print("\033[H\033[2J")
+		fmt.Print(bufferHelp + "\n\n")
+		for i, line := range buffer {
+			fmt.Printf(Blue+"%3d"+Reset+"  %s\n", i+1, line)
+		}
+		if message != "" {
+			fmt.Println(Yellow + "\n" + message + Reset)
+			message = ""
+		}
+		rl.SetPrompt("\nedit> ")
+		command, err := rl.Readline()
+		if err != nil {
+			return content
+		}
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "w" || fields[0] == "done":
+			var lines []string
+			for _, line := range buffer {
+				if strings.TrimSpace(line) != "" {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, "\n")
+		case fields[0] == "q":
+			return content
+		case fields[0] == "u":
+			if len(undo) == 0 {
+				message = "Nothing to undo."
+				continue
+			}
+			buffer = undo[len(undo)-1]
+			undo = undo[:len(undo)-1]
+		case fields[0] == "a":
+			insert(len(buffer))
+		case fields[0] == "i" && len(fields) == 2:
+			n, ok := lineNumber(fields[1], len(buffer)+1)
+			if !ok {
+				message = "No line " + fields[1]
+				continue
+			}
+			insert(n)
+		case fields[0] == "d" && len(fields) == 2:
+			from, to, isRange := strings.Cut(fields[1], "-")
+			if !isRange {
+				to = from
+			}
+			first, ok1 := lineNumber(from, len(buffer))
+			last, ok2 := lineNumber(to, len(buffer))
+			if !ok1 || !ok2 || last < first {
+				message = "No lines " + fields[1]
+				continue
+			}
+			save()
+			buffer = append(buffer[:first], buffer[last+1:]...)
+		case fields[0] == "m" && len(fields) == 3:
+			from, ok1 := lineNumber(fields[1], len(buffer))
+			to, ok2 := lineNumber(fields[2], len(buffer))
+			if !ok1 || !ok2 {
+				message = "Usage: m <line> <new position>"
+				continue
+			}
+			save()
+			line := buffer[from]
+			buffer = append(buffer[:from], buffer[from+1:]...)
+			buffer = append(buffer[:to], append([]string{line}, buffer[to:]...)...)
+		case len(fields) == 1:
+			n, ok := lineNumber(fields[0], len(buffer))
+			if !ok {
+				message = "Unknown command. " + bufferHelp
+				continue
+			}
+			rl.SetPrompt(fmt.Sprintf("%d> ", n+1))
+			line, err := rl.ReadlineWithDefault(buffer[n])
+			if err != nil || line == buffer[n] {
+				continue
+			}
+			save()
+			buffer[n] = line
+		default:
+			message = "Unknown command. " + bufferHelp
+		}
+	}
+}
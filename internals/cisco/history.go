@@ -0,0 +1,166 @@
+package cisco
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// Every change applied by ReviewConfig is recorded as one JSON line
+const HistoryFile = "changes.jsonl"
+
+// DeviceInfo identifies the device a change was applied to
+type DeviceInfo struct {
+	PID          string `json:"pid,omitempty"`
+	SerialNumber string `json:"serialnumber,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// ChangeContext tells where the configuration reviewed by ReviewConfig comes from
+type ChangeContext struct {
+	Device    DeviceInfo
+	Question  string
+	Model     string
+	SessionID string
+	// Change plan applied with aixedge-apply and the engineer who approved it
+	Plan     string
+	Approver string
+}
+
+// Change is set by the chat before each question
+var Change ChangeContext
+
+// LineResult is the result of one configuration line
+type LineResult struct {
+	Line  string `json:"line"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type ChangeRecord struct {
+	ID           string        `json:"id"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Device       DeviceInfo    `json:"device"`
+	SessionID    string        `json:"session_id,omitempty"`
+	Question     string        `json:"question,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	Operator     string        `json:"operator"`
+	Plan         string        `json:"plan,omitempty"`
+	Approver     string        `json:"approver,omitempty"`
+	Suggested    string        `json:"suggested"`
+	Edited       string        `json:"edited"`
+	Applied      bool          `json:"applied"`
+	Lines        []LineResult  `json:"lines"`
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// State is the state of the rollback of the change
+func (r ChangeRecord) State() string {
+	if !r.Applied {
+		return "failed"
+	}
+	return ChangeState(r.ID)
+}
+
+// Function returns who runs the assistant. IOS users reach the guestshell
+// as the same system user, AIXEDGE_OPERATOR can name the person.
+func operator() string {
+	if name := os.Getenv("AIXEDGE_OPERATOR"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// newChangeRecord fills the record from the context of the change
+func newChangeRecord(id string, suggested string, edited string) ChangeRecord {
+	return ChangeRecord{
+		ID:        id,
+		Timestamp: time.Now(),
+		Device:    Change.Device,
+		SessionID: Change.SessionID,
+		Question:  Change.Question,
+		Model:     Change.Model,
+		Operator:  operator(),
+		Plan:      Change.Plan,
+		Approver:  Change.Approver,
+		Suggested: suggested,
+		Edited:    edited,
+	}
+}
+
+// RecordChange appends the change to the history
+func RecordChange(record ChangeRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	// The history holds the applied configuration with its secrets, only the user can read it
+	file, err := os.OpenFile(HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Histories written by older versions were readable by everyone
+	if err := file.Chmod(0600); err != nil {
+		return err
+	}
+	_, err = file.Write(append(b, '\n'))
+	return err
+}
+
+// UpdateChange replaces the record of the change with the same ID
+func UpdateChange(record ChangeRecord) error {
+	records, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	var out []byte
+	for _, r := range records {
+		if r.ID == record.ID {
+			r = record
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		out = append(append(out, b...), '\n')
+	}
+	if err := os.WriteFile(HistoryFile, out, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(HistoryFile, 0600)
+}
+
+// LoadHistory returns the recorded changes, oldest first
+func LoadHistory() ([]ChangeRecord, error) {
+	file, err := os.Open(HistoryFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []ChangeRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record ChangeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, fmt.Errorf("%s line %d: %v", HistoryFile, n, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,439 @@
+package cisco
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LintLevel int
+
+const (
+	// LintWarning is applied as is but may not do what was meant
+	LintWarning LintLevel = iota
+	// LintError is rejected by the device or configures something else
+	LintError
+)
+
+// LintFinding is a problem found in a proposed configuration line
+type LintFinding struct {
+	Level   LintLevel
+	Line    string
+	Message string
+}
+
+func (f LintFinding) String() string {
+	level := "warning"
+	if f.Level == LintError {
+		level = "error"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, f.Line, f.Message)
+}
+
+// Commands only valid in interface configuration mode
+var interfaceCommands = []string{
+	"switchport", "shutdown", "speed", "duplex", "channel-group", "ip address", "ipv6 address",
+	"ip helper-address", "power inline", "spanning-tree portfast", "spanning-tree bpduguard",
+	"storm-control", "encapsulation", "ip access-group", "ip ospf", "standby", "vrrp", "mtu",
+	"ip nat inside", "ip nat outside", "dot1x", "mab",
+}
+
+// Global forms of interface commands, e.g. the portfast default of every access port
+var globalForms = []string{
+	"spanning-tree portfast default", "spanning-tree portfast bpduguard default", "spanning-tree portfast bpdufilter default",
+	"spanning-tree portfast edge default", "spanning-tree portfast edge bpduguard default",
+	"spanning-tree portfast edge bpdufilter default", "spanning-tree portfast network default",
+	"dot1x system-auth-control", "dot1x critical", "dot1x guest-vlan supplicant", "dot1x logging", "mab logging",
+	"ip nat inside source", "ip nat outside source", "ip ospf name-lookup",
+}
+
+// Commands whose argument is free text, it may contain <, > or [ ]
+var freeTextCommands = []string{"description", "remark"}
+
+// Interface commands that are not valid in any other sub-mode either
+var interfaceOnlyCommands = []string{
+	"switchport", "ip address", "channel-group", "spanning-tree portfast", "speed", "duplex", "power inline",
+}
+
+// Commands of a routing process or a DHCP pool, not valid in global configuration
+var modeCommands = []string{
+	"network", "neighbor", "redistribute", "router-id", "passive-interface", "default-information originate",
+	"maximum-paths", "auto-summary", "default-router", "dns-server",
+}
+
+// Global commands. Below a sub-mode they mean an "exit" is missing.
+var globalCommands = []string{
+	"hostname", "ip route", "ipv6 route", "ip domain name", "ip domain-name", "ip name-server", "ntp server",
+	"logging host", "snmp-server", "username", "enable secret", "spanning-tree mode", "vtp", "ip default-gateway",
+	"banner", "access-list", "aaa new-model", "ip routing", "ipv6 unicast-routing", "ip dhcp excluded-address",
+	"clock timezone",
+}
+
+// Exec commands, they fail in configuration mode
+var execCommands = []string{
+	"show", "ping", "traceroute", "copy", "reload", "clear", "debug", "undebug", "erase", "delete", "dir", "more", "terminal",
+}
+
+// Commands that may be repeated with different values in the same block
+var multiValueCommands = []string{"ip helper-address"}
+
+// Interfaces created by the configuration itself
+var virtualInterfaces = []string{"Vlan", "Loopback", "Port-channel", "Tunnel", "BDI", "Dialer"}
+
+var (
+	cliPrompt   = regexp.MustCompile(`^[A-Za-z0-9_.-]+(\([a-z0-9-]+\))?[#>]`)
+	placeholder = regexp.MustCompile(`<[^>]*[a-z][^>]*>|\b[xX]\.[xX]\.[xX]\.[xX]\b|\[[a-zA-Z ]+\]`)
+)
+
+// hasCommand tells whether the line is one of the commands, with or without "no"
+func hasCommand(commands []string, line string) bool {
+	line = strings.TrimPrefix(strings.ToLower(normalize(line)), "no ")
+	for _, command := range commands {
+		if line == command || strings.HasPrefix(line, command+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// isInterfaceCommand tells whether the line is only valid in interface configuration mode
+func isInterfaceCommand(commands []string, line string) bool {
+	return hasCommand(commands, line) && !hasCommand(globalForms, line)
+}
+
+// Types of banner, "banner ^C text ^C" is the motd banner
+var bannerTypes = []string{"motd", "login", "exec", "incoming", "slip-ppp", "prompt-timeout", "config-save"}
+
+// stripBanners removes the text of the banners, from the line after the
+// banner command to the line with the closing delimiter. It is not configuration.
+func stripBanners(config string) string {
+	var kept []string
+	delimiter := ""
+	for _, line := range strings.Split(strings.ReplaceAll(config, "\r", ""), "\n") {
+		if delimiter != "" {
+			if strings.Contains(line, delimiter) {
+				delimiter = ""
+			}
+			continue
+		}
+		kept = append(kept, line)
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.ToLower(fields[0]) != "banner" {
+			continue
+		}
+		text := strings.Join(fields[1:], " ")
+		if isInList(bannerTypes, strings.ToLower(fields[1])) {
+			text = strings.Join(fields[2:], " ")
+		}
+		if text == "" {
+			continue
+		}
+		// The first character is the delimiter, ^C is written as two characters
+		open := text[:1]
+		if strings.HasPrefix(text, "^C") {
+			open = "^C"
+		}
+		if !strings.Contains(text[len(open):], open) {
+			delimiter = open
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// LintConfig checks a proposed configuration without the device. The interfaces
+// are the ones of the device, when known names of physical interfaces are checked.
+func LintConfig(config string, interfaces []string) []LintFinding {
+	var findings []LintFinding
+	add := func(level LintLevel, line string, format string, args ...any) {
+		findings = append(findings, LintFinding{level, line, fmt.Sprintf(format, args...)})
+	}
+	config = stripBanners(config)
+
+	// Syntax of the lines as written
+	for _, raw := range strings.Split(strings.ReplaceAll(config, "\r", ""), "\n") {
+		line := strings.Join(strings.Fields(raw), " ")
+		lower := strings.ToLower(line)
+		if line == "" || isInList(sessionCommands, lower) {
+			continue
+		}
+		first := strings.Fields(lower)[0]
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") ||
+			strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			add(LintError, line, "text of the answer, not a configuration command")
+		case cliPrompt.MatchString(line):
+			add(LintError, line, "contains a CLI prompt")
+		case isInList(execCommands, first):
+			add(LintError, line, "exec command, it fails in configuration mode (use \"do %s\")", line)
+		}
+		if placeholder.MatchString(line) && !hasCommand(freeTextCommands, line) {
+			add(LintError, line, "contains a placeholder to replace with a real value")
+		}
+	}
+
+	lines := ParseProposedConfig(config)
+	for _, line := range lines {
+		lintLine(line, interfaces, add)
+		mode := strings.ToLower(line.Text)
+		isInterface := strings.HasPrefix(mode, "interface ")
+		switch {
+		case !IsSubMode(line.Text) && isInterfaceCommand(interfaceCommands, line.Text):
+			add(LintError, line.Text, "interface command outside of an interface")
+		case !IsSubMode(line.Text) && hasCommand(modeCommands, line.Text):
+			add(LintError, line.Text, "not a global command, it belongs to a routing process or a DHCP pool")
+		}
+		for _, child := range line.Children {
+			lintLine(child, interfaces, add)
+			switch {
+			case !isInterface && isInterfaceCommand(interfaceOnlyCommands, child.Text):
+				add(LintError, child.Text, "interface command under \"%s\"", line.Text)
+			case hasCommand(globalCommands, child.Text) || hasCommand(globalForms, child.Text):
+				add(LintWarning, child.Text, "global command under \"%s\", is an \"exit\" missing?", line.Text)
+			}
+		}
+		lintBlock(line.Text, line.Children, add)
+	}
+	lintBlock("", lines, add)
+	return findings
+}
+
+// lintLine checks the values of a line
+func lintLine(line *ConfigLine, interfaces []string, add func(LintLevel, string, string, ...any)) {
+	fields := strings.Fields(line.Text)
+	lower := strings.Fields(strings.ToLower(line.Text))
+	// Values are checked when they start with a digit, "vlan internal ..." or "ip route vrf ..." are other commands
+	numeric := func(i int) bool {
+		return len(lower) > i && lower[i][0] >= '0' && lower[i][0] <= '9'
+	}
+	switch {
+	case lower[0] == "vlan" && numeric(1):
+		if msg := checkVlans(lower[1], true); msg != "" {
+			add(LintError, line.Text, "%s", msg)
+		}
+	case len(lower) >= 4 && lower[0] == "switchport" && (lower[1] == "access" || lower[1] == "voice") && lower[2] == "vlan":
+		if lower[1] == "voice" && (lower[3] == "dot1p" || lower[3] == "untagged" || lower[3] == "none") {
+			break
+		}
+		if msg := checkVlans(lower[3], false); msg != "" {
+			add(LintError, line.Text, "%s", msg)
+		}
+	case len(lower) >= 5 && lower[0] == "switchport" && lower[1] == "trunk" && lower[2] == "native" && lower[3] == "vlan":
+		if msg := checkVlans(lower[4], false); msg != "" {
+			add(LintError, line.Text, "%s", msg)
+		}
+	case len(lower) >= 5 && lower[0] == "switchport" && lower[1] == "trunk" && lower[2] == "allowed" && lower[3] == "vlan":
+		list := lower[4]
+		if isInList([]string{"add", "remove", "except"}, list) && len(lower) >= 6 {
+			list = lower[5]
+		}
+		if list != "all" && list != "none" {
+			if msg := checkVlans(list, false); msg != "" {
+				add(LintError, line.Text, "%s", msg)
+			}
+		}
+	case len(fields) >= 2 && lower[0] == "interface":
+		for _, name := range interfaceNames(strings.Join(fields[1:], " ")) {
+			if msg := deviceInterface(name, interfaces); msg != "" {
+				add(LintError, line.Text, "%s", msg)
+				break
+			}
+		}
+	case len(lower) >= 3 && lower[0] == "ip" && lower[1] == "address" && numeric(2) && strings.Contains(lower[2], "/"):
+		if _, _, err := net.ParseCIDR(lower[2]); err != nil {
+			add(LintError, line.Text, "%s is not an IPv4 prefix", fields[2])
+		}
+	case len(lower) >= 4 && lower[0] == "ip" && lower[1] == "address" && numeric(2):
+		if net.ParseIP(lower[2]).To4() == nil {
+			add(LintError, line.Text, "%s is not an IPv4 address", fields[2])
+		} else if !isMask(lower[3]) {
+			add(LintError, line.Text, "%s is not a subnet mask", fields[3])
+		}
+	case len(lower) >= 5 && lower[0] == "ip" && lower[1] == "route" && numeric(2):
+		if net.ParseIP(lower[2]).To4() == nil {
+			add(LintError, line.Text, "%s is not an IPv4 prefix", fields[2])
+		} else if !isMask(lower[3]) {
+			add(LintError, line.Text, "%s is not a subnet mask", fields[3])
+		}
+	}
+}
+
+// checkVlans checks a VLAN id or a list such as 10,20-30. Reserved VLANs can not be created.
+func checkVlans(list string, create bool) string {
+	for _, item := range strings.Split(list, ",") {
+		low, high, isRange := strings.Cut(item, "-")
+		if !isRange {
+			high = low
+		}
+		first, err1 := strconv.Atoi(low)
+		last, err2 := strconv.Atoi(high)
+		switch {
+		case err1 != nil || err2 != nil:
+			return fmt.Sprintf("%s is not a VLAN id", item)
+		case first < 1 || last > 4094:
+			return fmt.Sprintf("VLAN %s is out of the range 1-4094", item)
+		case first > last:
+			return fmt.Sprintf("VLAN range %s is reversed", item)
+		case create && first <= 1005 && last >= 1002:
+			return "VLANs 1002-1005 are reserved"
+		}
+	}
+	return ""
+}
+
+// interfaceNames expands "range Gi1/0/1 - 4, Gi1/0/10" into the names of the interfaces
+func interfaceNames(names string) []string {
+	members, isRange := strings.CutPrefix(names, "range ")
+	if !isRange {
+		return []string{names}
+	}
+	var expanded []string
+	for _, item := range strings.Split(members, ",") {
+		item = strings.ReplaceAll(item, " ", "")
+		base, end, ok := strings.Cut(item, "-")
+		if !ok {
+			expanded = append(expanded, item)
+			continue
+		}
+		// The number after the last slash, or after the type for Vlan10-20
+		start := strings.LastIndex(base, "/") + 1
+		if start == 0 {
+			start = max(strings.IndexAny(base, "0123456789"), 0)
+		}
+		first, err1 := strconv.Atoi(base[start:])
+		last, err2 := strconv.Atoi(end)
+		if err1 != nil || err2 != nil || last < first || last-first > 512 {
+			expanded = append(expanded, item)
+			continue
+		}
+		for n := first; n <= last; n++ {
+			expanded = append(expanded, base[:start]+strconv.Itoa(n))
+		}
+	}
+	return expanded
+}
+
+// deviceInterface checks the name of a physical interface against the ones of the device
+func deviceInterface(name string, interfaces []string) string {
+	if err := checkInterface(name); err != nil {
+		return err.Error()
+	}
+	full := ExpandInterface(name)
+	for _, virtual := range virtualInterfaces {
+		if strings.HasPrefix(full, virtual) {
+			return ""
+		}
+	}
+	if len(interfaces) == 0 {
+		return ""
+	}
+	base, _, _ := strings.Cut(full, ".")
+	for _, iface := range interfaces {
+		if strings.EqualFold(iface, base) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s does not exist on this device", full)
+}
+
+// isMask tells whether the address is a contiguous subnet mask
+func isMask(mask string) bool {
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return false
+	}
+	_, bits := net.IPMask(ip).Size()
+	return bits == 32
+}
+
+// lintBlock finds the lines of a block that repeat or undo each other
+func lintBlock(parent string, lines []*ConfigLine, add func(LintLevel, string, string, ...any)) {
+	where := ""
+	if parent != "" {
+		where = fmt.Sprintf(" under \"%s\"", parent)
+	}
+	for i, line := range lines {
+		if len(line.Children) > 0 || IsSubMode(line.Text) {
+			continue
+		}
+		text := normalize(line.Text)
+		for _, previous := range lines[:i] {
+			before := normalize(previous.Text)
+			switch {
+			case before == text:
+				add(LintWarning, line.Text, "duplicate line%s", where)
+			case strings.HasPrefix(text, "no ") && (before == text[3:] || strings.HasPrefix(before, text[3:]+" ")):
+				add(LintWarning, line.Text, "undoes \"%s\"%s", previous.Text, where)
+			case hasCommand(globalForms, text) || hasCommand(globalForms, before):
+				// Global forms are separate commands, not values of the interface one
+			default:
+				for _, command := range valueCommands {
+					if isInList(multiValueCommands, command) || strings.HasSuffix(text, " secondary") {
+						continue
+					}
+					if strings.HasPrefix(text, command+" ") && strings.HasPrefix(before, command+" ") {
+						add(LintWarning, line.Text, "replaces \"%s\"%s, only one value is kept", previous.Text, where)
+						break
+					}
+				}
+			}
+		}
+		if text == "switchport mode access" {
+			for _, other := range lines {
+				if strings.HasPrefix(normalize(other.Text), "switchport trunk ") {
+					add(LintWarning, other.Text, "trunk setting on an access port%s", where)
+				}
+			}
+		}
+	}
+}
+
+// DeviceInterfaces returns the names of the interfaces from show ip interface brief
+func DeviceInterfaces() ([]string, error) {
+	iosxe := IOSXE{}
+	out, err := iosxe.Command("show ip interface brief")
+	if err != nil {
+		return nil, err
+	}
+	var interfaces []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "Interface" || strings.IndexAny(fields[0], "0123456789") < 0 {
+			continue
+		}
+		interfaces = append(interfaces, fields[0])
+	}
+	return interfaces, nil
+}
+
+// PrintLint shows the findings, errors in red and warnings in yellow
+func PrintLint(findings []LintFinding) {
+	for _, finding := range findings {
+		colour := Yellow
+		if finding.Level == LintError {
+			colour = Red
+		}
+		fmt.Println(colour + "  " + finding.String() + Reset)
+	}
+}
+
+// lintReport lists the findings for the assistant
+func lintReport(findings []LintFinding) string {
+	var report []string
+	for _, finding := range findings {
+		report = append(report, finding.String())
+	}
+	return strings.Join(report, "\n")
+}
+
+// LintErrors returns the number of errors among the findings
+func LintErrors(findings []LintFinding) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Level == LintError {
+			count++
+		}
+	}
+	return count
+}
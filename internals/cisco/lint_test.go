@@ -0,0 +1,75 @@
+package cisco
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintConfig(t *testing.T) {
+	interfaces := []string{"GigabitEthernet1/0/1", "GigabitEthernet1/0/2", "Vlan10"}
+	tests := []struct {
+		name   string
+		config string
+		// Expected findings as "error: <message part>" or "warning: <message part>", none when empty
+		want []string
+	}{
+		{"portfast default", "spanning-tree portfast default", nil},
+		{"portfast bpduguard default", "spanning-tree portfast default\nspanning-tree portfast bpduguard default", nil},
+		{"portfast edge default", "spanning-tree portfast edge default\nspanning-tree portfast edge bpduguard default", nil},
+		{"dot1x system-auth-control", "aaa new-model\ndot1x system-auth-control", nil},
+		{"nat source", "ip nat inside source list 1 interface GigabitEthernet1/0/1 overload", nil},
+		{"banner motd", "banner motd ^C\n# Authorized access only\n- Disconnect now if you are not\n^C", nil},
+		{"banner with delimiter", "banner login #\n* Lab switch *\n<maintained by netops>\n#\nhostname acc-sw1", nil},
+		{"banner on one line", "banner exec $ Welcome $\nhostname acc-sw1", nil},
+		{"description", "interface GigabitEthernet1/0/1\n description <uplink to core>\n no shutdown", nil},
+		{"acl remark", "ip access-list extended MGMT\n remark [allow the jump hosts]\n permit tcp host 10.0.0.5 any eq 22", nil},
+		{"access port", "interface GigabitEthernet1/0/2\n switchport mode access\n switchport access vlan 10\n spanning-tree portfast", nil},
+
+		{"portfast outside of an interface", "spanning-tree portfast", []string{"error: interface command outside of an interface"}},
+		{"dot1x port control outside of an interface", "dot1x pae authenticator", []string{"error: interface command outside of an interface"}},
+		{"portfast under a routing process", "router ospf 1\n spanning-tree portfast", []string{"error: interface command under"}},
+		{"portfast default under an interface", "interface GigabitEthernet1/0/2\n spanning-tree portfast default", []string{"warning: global command under"}},
+		{"two values", "interface GigabitEthernet1/0/2\n switchport access vlan 10\n switchport access vlan 20", []string{"warning: replaces"}},
+		{"placeholder", "interface GigabitEthernet1/0/2\n switchport access vlan <vlan-id>", []string{"error: contains a placeholder", "error: <vlan-id> is not a VLAN id"}},
+		{"answer text", "# Configure the port", []string{"error: text of the answer"}},
+		{"text after a banner", "banner motd ^C\nWelcome\n^C\n# Configure the port", []string{"error: text of the answer"}},
+		{"exec command", "show running-config", []string{"error: exec command"}},
+		{"unknown interface", "interface GigabitEthernet1/0/9\n shutdown", []string{"error: GigabitEthernet1/0/9 does not exist"}},
+		{"reserved VLAN", "vlan 1002", []string{"error: VLANs 1002-1005 are reserved"}},
+		{"missing exit", "interface Vlan10\n ip address 10.0.10.2 255.255.255.0\n hostname acc-sw1", []string{"warning: global command under"}},
+	}
+	for _, test := range tests {
+		findings := LintConfig(test.config, interfaces)
+		var got []string
+		for _, finding := range findings {
+			got = append(got, finding.String())
+		}
+		if len(findings) != len(test.want) {
+			t.Errorf("%s: LintConfig(%q) = %q, want %d findings %q", test.name, test.config, got, len(test.want), test.want)
+			continue
+		}
+		for i, want := range test.want {
+			level, message, _ := strings.Cut(want, ": ")
+			if !strings.HasPrefix(got[i], level+": ") || !strings.Contains(got[i], message) {
+				t.Errorf("%s: finding %d = %q, want %q", test.name, i, got[i], want)
+			}
+		}
+	}
+}
+
+func TestStripBanners(t *testing.T) {
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{"banner motd ^C\nline one\nline two ^C\nhostname sw1", "banner motd ^C\nhostname sw1"},
+		{"banner #\ntext\n#", "banner #"},
+		{"banner login % single line %\nhostname sw1", "banner login % single line %\nhostname sw1"},
+		{"banner motd\nhostname sw1", "banner motd\nhostname sw1"},
+	}
+	for _, test := range tests {
+		if got := stripBanners(test.config); got != test.want {
+			t.Errorf("stripBanners(%q) = %q, want %q", test.config, got, test.want)
+		}
+	}
+}
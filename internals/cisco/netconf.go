@@ -0,0 +1,140 @@
+package cisco
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// End of message delimiter of NETCONF 1.0 framing
+const netconfEOM = "]]>]]>"
+
+const netconfHello = `<?xml version="1.0" encoding="UTF-8"?>
+<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <capabilities>
+    <capability>urn:ietf:params:netconf:base:1.0</capability>
+  </capabilities>
+</hello>`
+
+// NETCONF retrieves YANG data as XML over the "netconf" SSH subsystem.
+// Only base:1.0 is advertised so the device keeps end-of-message framing.
+type NETCONF struct {
+	Address  string
+	Port     int
+	Username string
+	Password string
+	// Host key verification, as for SSH
+	HostKey    string
+	KnownHosts string
+	Timeout    time.Duration
+}
+
+// Get runs a <get> (or <get-config> of running when config is true) with a subtree filter
+func (n *NETCONF) Get(filter string, config bool) (string, error) {
+	port := n.Port
+	if port == 0 {
+		port = 830
+	}
+	timeout := n.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	hostKeyCallback, err := HostKeyCallback(n.HostKey, n.KnownHosts)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", n.Address, err)
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(n.Address, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:            n.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(n.Password)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return "", fmt.Errorf("connecting to %s: %v", n.Address, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("opening session on %s: %v", n.Address, err)
+	}
+	defer session.Close()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		return "", fmt.Errorf("netconf subsystem on %s: %v", n.Address, err)
+	}
+	reader := bufio.NewReader(stdout)
+
+	if _, err := readNetconfMessage(reader); err != nil {
+		return "", fmt.Errorf("reading hello: %v", err)
+	}
+	if err := writeNetconfMessage(stdin, netconfHello); err != nil {
+		return "", err
+	}
+
+	operation := "get"
+	source := ""
+	if config {
+		operation = "get-config"
+		source = "<source><running/></source>"
+	}
+	rpc := fmt.Sprintf(`<rpc message-id="1" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><%s>%s<filter type="subtree">%s</filter></%s></rpc>`,
+		operation, source, filter, operation)
+	if err := writeNetconfMessage(stdin, rpc); err != nil {
+		return "", err
+	}
+	reply, err := readNetconfMessage(reader)
+	if err != nil {
+		return "", fmt.Errorf("reading reply: %v", err)
+	}
+	if err := writeNetconfMessage(stdin, `<rpc message-id="2" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><close-session/></rpc>`); err != nil {
+		return "", fmt.Errorf("closing session on %s: %v", n.Address, err)
+	}
+
+	if strings.Contains(reply, "<rpc-error>") {
+		return "", fmt.Errorf("rpc-error from %s: %s", n.Address, reply)
+	}
+	return reply, nil
+}
+
+func (n *NETCONF) Data(kind string) (string, error) {
+	model, ok := YangModels[kind]
+	if !ok {
+		return "", fmt.Errorf("no YANG model for %s", kind)
+	}
+	filter := fmt.Sprintf(`<%s xmlns="%s"/>`, model.Container, model.Namespace)
+	return n.Get(filter, kind == "config")
+}
+
+func writeNetconfMessage(w io.Writer, message string) error {
+	_, err := io.WriteString(w, message+netconfEOM)
+	return err
+}
+
+func readNetconfMessage(r *bufio.Reader) (string, error) {
+	var message bytes.Buffer
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return message.String(), err
+		}
+		message.WriteByte(b)
+		if bytes.HasSuffix(message.Bytes(), []byte(netconfEOM)) {
+			return strings.TrimSpace(strings.TrimSuffix(message.String(), netconfEOM)), nil
+		}
+	}
+}
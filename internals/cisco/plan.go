@@ -0,0 +1,347 @@
+package cisco
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Change plans let a second engineer approve a change before it is applied.
+// ReviewConfig saves the plan signed with the key of its author, aixedge-approve
+// signs it with the key of the approver and aixedge-apply applies it once both
+// signatures check out.
+const PlanDir = "plans"
+
+// Plan is a proposed change saved for review
+type Plan struct {
+	ID        string     `json:"id"`
+	Created   time.Time  `json:"created"`
+	Author    string     `json:"author"`
+	AuthorKey string     `json:"author_key"`
+	Device    DeviceInfo `json:"device"`
+	Question  string     `json:"question,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	SessionID string     `json:"session_id,omitempty"`
+	Suggested string     `json:"suggested"`
+	Config    string     `json:"config"`
+	// Diff against the running-config when the plan was made
+	Diff     string   `json:"diff"`
+	Risks    []Risk   `json:"risks,omitempty"`
+	Lint     []string `json:"lint,omitempty"`
+	Checksum string   `json:"checksum"`
+	// Signature of the checksum with the key of the author, not part of the checksum
+	AuthorSignature string `json:"author_signature"`
+	// Added by aixedge-approve, not part of the checksum
+	Approval *Approval `json:"approval,omitempty"`
+}
+
+// Approval is the signature of the plan checksum by an approver
+type Approval struct {
+	Approver  string    `json:"approver"`
+	Time      time.Time `json:"time"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+}
+
+// ApproverKey is the private key file of an engineer, used to sign the plans
+// they write and the plans they approve
+type ApproverKey struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"private_key"`
+}
+
+// SigningKeyFile returns the key file of the engineer: the given file,
+// AIXEDGE_APPROVE_KEY or ~/.aixedge/approver.key
+func SigningKeyFile(file string) string {
+	if file != "" {
+		return file
+	}
+	if file := os.Getenv("AIXEDGE_APPROVE_KEY"); file != "" {
+		return file
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aixedge", "approver.key")
+}
+
+// PlanPath returns the file of the plan
+func PlanPath(id string) string {
+	return filepath.Join(PlanDir, filepath.Base(id)+".json")
+}
+
+// NewPlan builds the plan of the configuration with its diff, risks and lint findings.
+// The plan is signed with the key of the author read from SigningKeyFile.
+func NewPlan(suggested string, config string, risks []Risk, findings []LintFinding) (*Plan, error) {
+	name, private, err := loadSigningKey(SigningKeyFile(""))
+	if err != nil {
+		return nil, fmt.Errorf("%v, create the key of the author with aixedge-approve --keygen <name>", err)
+	}
+	plan := &Plan{
+		ID:        NewChangeID(),
+		Created:   time.Now(),
+		Author:    name,
+		AuthorKey: publicKey(private),
+		Device:    Change.Device,
+		Question:  Change.Question,
+		Model:     Change.Model,
+		SessionID: Change.SessionID,
+		Suggested: suggested,
+		Config:    config,
+		Risks:     risks,
+	}
+	running, err := RunningConfig()
+	if err != nil {
+		return nil, errors.New("could not read the running-config for the diff")
+	}
+	plan.Diff = FormatDiff(DiffConfig(ParseProposedConfig(config), ParseConfig(running)), false)
+	for _, finding := range findings {
+		plan.Lint = append(plan.Lint, finding.String())
+	}
+	plan.Checksum = plan.digest()
+	plan.AuthorSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(private, authorMessage(plan)))
+	return plan, nil
+}
+
+// digest is the SHA-256 of the plan without its checksum and signatures
+func (p *Plan) digest() string {
+	content := *p
+	content.Checksum = ""
+	content.AuthorSignature = ""
+	content.Approval = nil
+	b, _ := json.Marshal(content)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// SavePlan writes the plan to the plans directory
+func SavePlan(plan *Plan) error {
+	if err := os.MkdirAll(PlanDir, 0700); err != nil {
+		return err
+	}
+	return WritePlan(plan, PlanPath(plan.ID))
+}
+
+// WritePlan writes the plan to the file. The configuration may hold secrets, so only
+// the user can read it, the plan is handed to the approver deliberately.
+func WritePlan(plan *Plan, file string) error {
+	b, err := json.MarshalIndent(plan, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(b, '\n'), 0600); err != nil {
+		return err
+	}
+	// Plans written by older versions were readable by everyone
+	return os.Chmod(file, 0600)
+}
+
+// LoadPlan reads a plan from its file or its ID. It returns the file read.
+func LoadPlan(name string) (*Plan, string, error) {
+	file := name
+	if _, err := os.Stat(file); err != nil {
+		file = PlanPath(name)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("plan %s not found", name)
+	}
+	var plan Plan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, "", fmt.Errorf("%s: %v", file, err)
+	}
+	return &plan, file, nil
+}
+
+// The author signs the plan and its checksum
+func authorMessage(plan *Plan) []byte {
+	return []byte(fmt.Sprintf("aixedge-plan-author %s %s", plan.ID, plan.Checksum))
+}
+
+// The approval signs the plan, the approver and the time together
+func approvalMessage(plan *Plan, approver string, at time.Time) []byte {
+	return []byte(fmt.Sprintf("aixedge-plan %s %s %s %s", plan.ID, plan.Checksum, approver, at.UTC().Format(time.RFC3339)))
+}
+
+// GenerateApproverKey creates the key pair of an approver. The private key is written
+// to the file, the public key is returned to be added to the approvers of the configuration.
+func GenerateApproverKey(name string, file string) (string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(ApproverKey{Name: name, PrivateKey: base64.StdEncoding.EncodeToString(private)})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(public), nil
+}
+
+// Function reads the name and the private key of a key file
+func loadSigningKey(keyFile string) (string, ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read the signing key: %v", err)
+	}
+	var key ApproverKey
+	if err := json.Unmarshal(b, &key); err != nil {
+		return "", nil, fmt.Errorf("%s is not a signing key", keyFile)
+	}
+	private, err := base64.StdEncoding.DecodeString(key.PrivateKey)
+	if err != nil || len(private) != ed25519.PrivateKeySize {
+		return "", nil, fmt.Errorf("%s is not a signing key", keyFile)
+	}
+	return key.Name, ed25519.PrivateKey(private), nil
+}
+
+func publicKey(private ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey))
+}
+
+// Function checks a base64 signature of the message with a base64 public key
+func verifySignature(key string, message []byte, signature string) bool {
+	public, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	return err == nil && ed25519.Verify(public, message, sig)
+}
+
+// ApprovePlan signs the plan with the key of the approver, which must not be the key of its author
+func ApprovePlan(plan *Plan, keyFile string) error {
+	if plan.digest() != plan.Checksum {
+		return errors.New("the plan was modified after it was created")
+	}
+	if !verifySignature(plan.AuthorKey, authorMessage(plan), plan.AuthorSignature) {
+		return errors.New("the plan is not signed by its author")
+	}
+	name, private, err := loadSigningKey(keyFile)
+	if err != nil {
+		return err
+	}
+	public := publicKey(private)
+	if public == plan.AuthorKey {
+		return errors.New("the plan is signed with this key, its author can not approve it")
+	}
+	at := time.Now().UTC().Truncate(time.Second)
+	plan.Approval = &Approval{
+		Approver:  name,
+		Time:      at,
+		PublicKey: public,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(private, approvalMessage(plan, name, at))),
+	}
+	return nil
+}
+
+// VerifyPlan checks that the plan is unchanged, signed by its author and approved by
+// a trusted approver with another key. approvers maps the names to their public keys,
+// the author must be one of them too.
+func VerifyPlan(plan *Plan, approvers map[string]string) error {
+	if plan.digest() != plan.Checksum {
+		return errors.New("the plan was modified after it was created")
+	}
+	if trusted, ok := approvers[plan.Author]; !ok || trusted != plan.AuthorKey {
+		return fmt.Errorf("the plan is not signed with a registered key of %s", plan.Author)
+	}
+	if !verifySignature(plan.AuthorKey, authorMessage(plan), plan.AuthorSignature) {
+		return errors.New("the author signature is invalid")
+	}
+	approval := plan.Approval
+	if approval == nil {
+		return errors.New("the plan is not approved")
+	}
+	if approval.PublicKey == plan.AuthorKey {
+		return errors.New("the plan is approved with the key of its author")
+	}
+	trusted, ok := approvers[approval.Approver]
+	if !ok {
+		return fmt.Errorf("%s is not an approver of this device", approval.Approver)
+	}
+	if trusted != approval.PublicKey {
+		return fmt.Errorf("the plan is not signed with the key of %s", approval.Approver)
+	}
+	if !verifySignature(trusted, approvalMessage(plan, approval.Approver, approval.Time), approval.Signature) {
+		return errors.New("the approval signature is invalid")
+	}
+	return nil
+}
+
+// PrintPlan shows what the plan changes
+func PrintPlan(plan *Plan) {
+	fmt.Printf("Plan %s by %s, %s\n", plan.ID, plan.Author, plan.Created.Format(time.RFC1123))
+	fmt.Printf("Device: %s %s\n", plan.Device.PID, plan.Device.SerialNumber)
+	if plan.Question != "" {
+		fmt.Println("Question: " + plan.Question)
+	}
+	fmt.Println("\nConfiguration:")
+	fmt.Println(Green + strings.TrimRight(plan.Config, "\n") + Reset)
+	fmt.Println("\nChanges compared with the running-config when the plan was made:")
+	fmt.Print(plan.Diff)
+	if len(plan.Risks) > 0 {
+		fmt.Println("\nRisk assessment:")
+		PrintRisks(plan.Risks)
+	}
+	if len(plan.Lint) > 0 {
+		fmt.Println("\nLinter findings:")
+		for _, finding := range plan.Lint {
+			fmt.Println(Yellow + "  " + finding + Reset)
+		}
+	}
+	if plan.Approval != nil {
+		fmt.Printf("\nApproved by %s, %s\n", plan.Approval.Approver, plan.Approval.Time.Local().Format(time.RFC1123))
+	}
+}
+
+// ApplyPlan applies an approved plan through the same steps as ReviewConfig:
+// archive, apply, history, verification and confirmation
+func ApplyPlan(plan *Plan) string {
+	if Change.Device.SerialNumber != "" && plan.Device.SerialNumber != "" && Change.Device.SerialNumber != plan.Device.SerialNumber {
+		return fmt.Sprintf("The plan was made for device %s, this is %s.", plan.Device.SerialNumber, Change.Device.SerialNumber)
+	}
+	records, _ := LoadHistory()
+	for _, record := range records {
+		if record.Plan == plan.ID && record.Applied {
+			return fmt.Sprintf("The plan was already applied as change %s.", record.ID)
+		}
+	}
+
+	PrintPlan(plan)
+	// The running-config may have changed since the plan was approved
+	if running, err := RunningConfig(); err == nil {
+		fmt.Println("\nChanges compared with the running-config now:")
+		PrintDiff(DiffConfig(ParseProposedConfig(plan.Config), ParseConfig(running)))
+	}
+	if blocked := risksAbove(AssessRisk(plan.Config, LoadDeviceState()), RiskBlockScore); len(blocked) > 0 {
+		PrintRisks(blocked)
+		return "The risk policy blocks these lines, the plan was not applied."
+	}
+	if !confirm(fmt.Sprintf("\nApply plan %s approved by %s?", plan.ID, plan.Approval.Approver)) {
+		return "The plan was not applied."
+	}
+
+	Change.Question = plan.Question
+	Change.Model = plan.Model
+	Change.SessionID = plan.SessionID
+	Change.Plan = plan.ID
+	Change.Approver = plan.Approval.Approver
+	return applyChange(plan.Suggested, plan.Config)
+}
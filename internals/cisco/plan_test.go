@@ -0,0 +1,67 @@
+package cisco
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Function returns a plan signed by the author key, as NewPlan does without the device
+func signedPlan(t *testing.T, authorKey string) *Plan {
+	name, private, err := loadSigningKey(authorKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &Plan{ID: "20261019-101500-abc123", Author: name, AuthorKey: publicKey(private), Config: "interface Vlan10\n shutdown"}
+	plan.Checksum = plan.digest()
+	plan.AuthorSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(private, authorMessage(plan)))
+	return plan
+}
+
+func TestPlanApproval(t *testing.T) {
+	dir := t.TempDir()
+	keys := map[string]string{}
+	for _, name := range []string{"alice", "bob"} {
+		public, err := GenerateApproverKey(name, filepath.Join(dir, name+".key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[name] = public
+	}
+	aliceKey, bobKey := filepath.Join(dir, "alice.key"), filepath.Join(dir, "bob.key")
+
+	plan := signedPlan(t, aliceKey)
+	if err := ApprovePlan(plan, aliceKey); err == nil || !strings.Contains(err.Error(), "author") {
+		t.Errorf("ApprovePlan with the author key = %v, want a refusal", err)
+	}
+	if err := ApprovePlan(plan, bobKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPlan(plan, keys); err != nil {
+		t.Errorf("VerifyPlan = %v", err)
+	}
+
+	// Renaming the author does not let them approve their own plan
+	renamed := signedPlan(t, aliceKey)
+	renamed.Author = "carol"
+	renamed.Checksum = renamed.digest()
+	if err := ApprovePlan(renamed, aliceKey); err == nil {
+		t.Error("ApprovePlan accepted a plan whose author signature no longer matches")
+	}
+
+	// The approval of the author key is rejected even under another name
+	own := signedPlan(t, aliceKey)
+	_, private, _ := loadSigningKey(aliceKey)
+	own.Approval = &Approval{Approver: "mallory", PublicKey: keys["alice"],
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(private, approvalMessage(own, "mallory", own.Created)))}
+	if err := VerifyPlan(own, map[string]string{"alice": keys["alice"], "mallory": keys["alice"]}); err == nil {
+		t.Error("VerifyPlan accepted an approval with the author key")
+	}
+
+	// An author key that is not registered is rejected
+	if err := VerifyPlan(plan, map[string]string{"bob": keys["bob"]}); err == nil {
+		t.Error("VerifyPlan accepted a plan of an unregistered author")
+	}
+}
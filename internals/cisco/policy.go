@@ -0,0 +1,53 @@
+package cisco
+
+import "strings"
+
+// Show commands that are never sent to the device on behalf of the AI assistant,
+// mostly because their output is too large for a prompt.
+var showBlacklist = []string{
+	"show tech",
+	"show interfaces",
+	"show tech-support",
+}
+
+// Output modifiers that write the output to a file instead of returning it
+var pipeBlacklist = []string{
+	"redirect",
+	"tee",
+	"append",
+}
+
+// IsValidShowCommand is the command policy for everything the assistant runs on a device.
+func IsValidShowCommand(command string) bool {
+	// Check if the command starts with "show"
+	if !strings.HasPrefix(command, "show") {
+		return false
+	}
+
+	// A single command per call, commands can't be chained
+	if strings.ContainsAny(command, "\n\r;") {
+		return false
+	}
+
+	// Check the output modifiers, e.g. "show run | include vlan"
+	for _, modifier := range strings.Split(command, "|")[1:] {
+		fields := strings.Fields(strings.ToLower(modifier))
+		if len(fields) == 0 {
+			return false
+		}
+		for _, blacklistedModifier := range pipeBlacklist {
+			if strings.HasPrefix(blacklistedModifier, fields[0]) {
+				return false
+			}
+		}
+	}
+
+	// Check if the command is in the blacklist
+	for _, blacklistedCommand := range showBlacklist {
+		if strings.TrimSpace(strings.ToLower(command)) == blacklistedCommand {
+			return false
+		}
+	}
+	// If the command starts with "show" and is not in the blacklist, return true
+	return true
+}
@@ -0,0 +1,96 @@
+package cisco
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Backend returns structured operational or config data from the device.
+// When StructuredBackend is set the show tools use it instead of screen-scraping the CLI.
+type Backend interface {
+	Data(kind string) (string, error)
+}
+
+var StructuredBackend Backend
+
+// IOS-XE YANG model behind each kind of data
+type yangModel struct {
+	Module    string
+	Container string
+	Namespace string
+}
+
+var YangModels = map[string]yangModel{
+	"interfaces": {"Cisco-IOS-XE-interfaces-oper", "interfaces", "http://cisco.com/ns/yang/Cisco-IOS-XE-interfaces-oper"},
+	"cdp":        {"Cisco-IOS-XE-cdp-oper", "cdp-neighbor-details", "http://cisco.com/ns/yang/Cisco-IOS-XE-cdp-oper"},
+	"routes":     {"ietf-routing", "routing-state", "urn:ietf:params:xml:ns:yang:ietf-routing"},
+	"vlans":      {"Cisco-IOS-XE-vlan-oper", "vlans", "http://cisco.com/ns/yang/Cisco-IOS-XE-vlan-oper"},
+	"stp":        {"Cisco-IOS-XE-spanning-tree-oper", "stp-details", "http://cisco.com/ns/yang/Cisco-IOS-XE-spanning-tree-oper"},
+	"mac":        {"Cisco-IOS-XE-matm-oper", "matm-oper-data", "http://cisco.com/ns/yang/Cisco-IOS-XE-matm-oper"},
+	"arp":        {"Cisco-IOS-XE-arp-oper", "arp-data", "http://cisco.com/ns/yang/Cisco-IOS-XE-arp-oper"},
+	"hardware":   {"Cisco-IOS-XE-device-hardware-oper", "device-hardware-data", "http://cisco.com/ns/yang/Cisco-IOS-XE-device-hardware-oper"},
+	"config":     {"Cisco-IOS-XE-native", "native", "http://cisco.com/ns/yang/Cisco-IOS-XE-native"},
+}
+
+// RESTCONF retrieves YANG data as JSON.
+// BaseURL is the RESTCONF root, e.g. https://10.0.0.1/restconf
+type RESTCONF struct {
+	BaseURL  string
+	Username string
+	Password string
+	Insecure bool
+	Client   *http.Client
+}
+
+// Get fetches a data resource, e.g. "Cisco-IOS-XE-interfaces-oper:interfaces/interface=GigabitEthernet1%2F0%2F1"
+func (r *RESTCONF) Get(path string) (string, error) {
+	client := r.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				// Switches usually present a self-signed certificate
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: r.Insecure},
+			},
+		}
+	}
+	url := strings.TrimRight(r.BaseURL, "/") + "/data/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", "application/yang-data+json")
+	if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		return string(body), nil
+	case http.StatusNoContent:
+		return "{}", nil
+	default:
+		return "", fmt.Errorf("unexpected status code %d for %s", res.StatusCode, path)
+	}
+}
+
+func (r *RESTCONF) Data(kind string) (string, error) {
+	model, ok := YangModels[kind]
+	if !ok {
+		return "", fmt.Errorf("no YANG model for %s", kind)
+	}
+	return r.Get(model.Module + ":" + model.Container)
+}
@@ -0,0 +1,105 @@
+package cisco
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Function returns the file of a recorded response. ':' is not allowed in
+// module files, so it is replaced by '_' like '/'.
+func recordedFile(resource string) string {
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(resource)
+	return filepath.Join("testdata", "restconf", name+".json")
+}
+
+// Function starts a stand-in of the device RESTCONF server serving the recorded responses
+func newRecordedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resource, ok := strings.CutPrefix(req.URL.Path, "/restconf/data/")
+		if !ok || req.Method != http.MethodGet {
+			http.NotFound(w, req)
+			return
+		}
+		data, err := os.ReadFile(recordedFile(resource))
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yang-data+json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRESTCONFData(t *testing.T) {
+	server := newRecordedServer(t)
+	r := &RESTCONF{BaseURL: server.URL + "/restconf", Client: server.Client()}
+
+	for kind, model := range YangModels {
+		t.Run(kind, func(t *testing.T) {
+			resource := model.Module + ":" + model.Container
+			want, err := os.ReadFile(recordedFile(resource))
+			if err != nil {
+				t.Fatalf("no recorded response for %s: %v", kind, err)
+			}
+			got, err := r.Data(kind)
+			if err != nil {
+				t.Fatalf("Data(%q): %v", kind, err)
+			}
+			if got != string(want) {
+				t.Errorf("Data(%q) = %q, want the recorded %s", kind, got, resource)
+			}
+			var data map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(got), &data); err != nil {
+				t.Fatalf("Data(%q) is not JSON: %v", kind, err)
+			}
+			if _, ok := data[resource]; !ok {
+				t.Errorf("Data(%q) has no %s container", kind, resource)
+			}
+		})
+	}
+}
+
+func TestRESTCONFDataErrors(t *testing.T) {
+	server := newRecordedServer(t)
+	r := &RESTCONF{BaseURL: server.URL + "/restconf", Client: server.Client()}
+
+	if _, err := r.Data("optics"); err == nil {
+		t.Error("Data of a kind without YANG model returned no error")
+	}
+	if _, err := r.Get("Cisco-IOS-XE-native:native/hostname"); err == nil {
+		t.Error("Get of a resource not recorded returned no error")
+	}
+}
+
+func TestRESTCONFRequest(t *testing.T) {
+	var accept, username, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		username, password, _ = req.BasicAuth()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r := &RESTCONF{BaseURL: server.URL + "/restconf/", Username: "admin", Password: "secret", Client: server.Client()}
+	got, err := r.Data("vlans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "{}" {
+		t.Errorf("Data of an empty resource = %q, want {}", got)
+	}
+	if accept != "application/yang-data+json" {
+		t.Errorf("Accept = %q", accept)
+	}
+	if username != "admin" || password != "secret" {
+		t.Errorf("basic auth = %q/%q", username, password)
+	}
+}
@@ -0,0 +1,248 @@
+package cisco
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Risk policy: lines scoring RiskConfirmScore or more are applied only when the
+// user types them again, lines scoring RiskBlockScore or more are never applied.
+var (
+	RiskConfirmScore = 50
+	RiskBlockScore   = 100
+)
+
+// Risk is a proposed line that may disrupt the network or cut the device off
+type Risk struct {
+	Line   string `json:"line"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// DeviceState is what the risk assessment needs to know about the device
+type DeviceState struct {
+	// Local interfaces with a CDP neighbour, most likely the uplinks
+	Uplinks []string
+	// Interfaces the device is managed through: SVIs and management ports with an
+	// address, and the interface towards the default gateway
+	Management     []string
+	DefaultGateway string
+	// Show commands whose output could not be read, the assessment then fails closed
+	Unreadable []string
+}
+
+var (
+	cdpInterface = regexp.MustCompile(`Interface: ([^,\s]+),`)
+	lastResort   = regexp.MustCompile(`Gateway of last resort is (\S+) to network 0\.0\.0\.0`)
+	// Without ip routing, e.g. on an L2 access switch, show ip route prints the ip default-gateway
+	defaultGateway = regexp.MustCompile(`Default gateway is (\S+)`)
+	cliError       = regexp.MustCompile(`(?m)^\s*% (?:Invalid|Incomplete|Ambiguous)`)
+	connectedRoute = regexp.MustCompile(`(?m)^[CS]\*?\s+(\S+) is directly connected, (\S+)`)
+)
+
+// LoadDeviceState gathers the state of the device from the CLI. The structured
+// backend is not used, the outputs are parsed as CLI text. Commands that fail or
+// print something else are listed in Unreadable.
+func LoadDeviceState() DeviceState {
+	var state DeviceState
+	iosxe := IOSXE{}
+	outputs := iosxe.Commands([]string{"show cdp neighbors detail", "show ip interface brief", "show ip route"})
+	// Output printed by each command whatever the state of the device, show ip route
+	// prints one or the other depending on ip routing
+	expected := [][]string{{""}, {"Interface"}, {"Gateway of last resort", "Default gateway is"}}
+	for i, out := range outputs {
+		printed := false
+		for _, text := range expected[i] {
+			printed = printed || strings.Contains(out.Output, text)
+		}
+		if out.Err != nil || !printed || cliError.MatchString(out.Output) {
+			state.Unreadable = append(state.Unreadable, out.Command)
+		}
+	}
+	cdp, brief, routes := outputs[0].Output, outputs[1].Output, outputs[2].Output
+
+	for _, match := range cdpInterface.FindAllStringSubmatch(cdp, -1) {
+		if !isInList(state.Uplinks, match[1]) {
+			state.Uplinks = append(state.Uplinks, match[1])
+		}
+	}
+	for _, line := range strings.Split(brief, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || net.ParseIP(fields[1]) == nil || fields[len(fields)-1] != "up" {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if strings.HasPrefix(name, "vlan") || strings.HasPrefix(name, "mgmt") || name == "gigabitethernet0/0" {
+			state.Management = append(state.Management, fields[0])
+		}
+	}
+	if match := lastResort.FindStringSubmatch(routes); match != nil {
+		state.DefaultGateway = match[1]
+	} else if match := defaultGateway.FindStringSubmatch(routes); match != nil {
+		state.DefaultGateway = match[1]
+	}
+	for _, match := range connectedRoute.FindAllStringSubmatch(routes, -1) {
+		_, subnet, err := net.ParseCIDR(match[1])
+		towardsGateway := err == nil && subnet.Contains(net.ParseIP(state.DefaultGateway))
+		if (towardsGateway || match[1] == "0.0.0.0/0") && !isInList(state.Management, match[2]) {
+			state.Management = append(state.Management, match[2])
+		}
+	}
+	return state
+}
+
+// uses tells whether the interfaces of the line include one of the list
+func uses(iface string, list []string) string {
+	for _, name := range interfaceNames(iface) {
+		full := ExpandInterface(name)
+		for _, item := range list {
+			if strings.EqualFold(full, item) {
+				return item
+			}
+		}
+	}
+	return ""
+}
+
+// AssessRisk scores the proposed lines that may disrupt the network
+func AssessRisk(config string, state DeviceState) []Risk {
+	var risks []Risk
+	add := func(line string, score int, format string, args ...any) {
+		risks = append(risks, Risk{line, score, fmt.Sprintf(format, args...)})
+	}
+	for _, line := range ParseProposedConfig(config) {
+		assessLine(line.Text, add, state)
+		fields := strings.Fields(line.Text)
+		if len(fields) < 2 || strings.ToLower(fields[0]) != "interface" {
+			for _, child := range line.Children {
+				assessLine(child.Text, add, state)
+			}
+			continue
+		}
+		iface := strings.Join(fields[1:], " ")
+		uplink := uses(iface, state.Uplinks)
+		management := uses(iface, state.Management)
+		for _, child := range line.Children {
+			text := strings.ToLower(normalize(child.Text))
+			switch {
+			case text == "shutdown" && uplink != "":
+				add(child.Text, 90, "shuts down %s, the CDP uplink", uplink)
+			case text == "shutdown" && management != "":
+				add(child.Text, 90, "shuts down %s, the device is managed through it", management)
+			case isAddressChange(text) && management != "":
+				add(child.Text, 80, "changes the address of %s, the device is managed through it", management)
+			case (text == "shutdown" || isAddressChange(text)) && len(state.Unreadable) > 0:
+				// Without the state any interface may be the uplink or the management one
+				add(child.Text, 70, "%s may be the uplink or the management interface, %s could not be read",
+					iface, strings.Join(state.Unreadable, ", "))
+			case strings.HasPrefix(text, "switchport") || strings.HasPrefix(text, "no switchport") || strings.HasPrefix(text, "channel-group"):
+				if uplink != "" {
+					add(child.Text, 70, "changes the switching of %s, the CDP uplink", uplink)
+				} else if strings.HasPrefix(text, "switchport trunk allowed vlan ") && !isAllowedVlanChange(text) {
+					add(child.Text, 50, "replaces the allowed VLANs of the trunk, \"add\" or \"remove\" only changes the listed ones")
+				}
+			case strings.HasPrefix(text, "ip access-group") && uplink != "":
+				add(child.Text, 60, "filters the traffic of %s, the CDP uplink", uplink)
+			case strings.HasPrefix(text, "ip access-group") && management != "":
+				add(child.Text, 60, "filters the traffic of %s, the device is managed through it", management)
+			default:
+				assessLine(child.Text, add, state)
+			}
+		}
+	}
+	return risks
+}
+
+func isAddressChange(text string) bool {
+	return strings.HasPrefix(text, "ip address") || strings.HasPrefix(text, "no ip address") || text == "no ip vrf forwarding" ||
+		strings.HasPrefix(text, "vrf forwarding") || strings.HasPrefix(text, "ip vrf forwarding")
+}
+
+func isAllowedVlanChange(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) > 4 && isInList([]string{"add", "remove", "except"}, fields[4])
+}
+
+// assessLine scores the lines whose risk does not depend on the interface they are under
+func assessLine(line string, add func(string, int, string, ...any), state DeviceState) {
+	text := strings.TrimPrefix(strings.ToLower(normalize(line)), "do ")
+	fields := strings.Fields(text)
+	switch {
+	case text == "reload" || strings.HasPrefix(text, "reload "):
+		add(line, 100, "reloads the device")
+	case text == "write erase" || strings.HasPrefix(text, "erase ") || strings.HasPrefix(text, "format "):
+		add(line, 100, "erases the saved configuration or the flash")
+	case strings.HasPrefix(text, "delete "):
+		add(line, 90, "deletes files from the device")
+	case strings.HasPrefix(text, "crypto key zeroize"):
+		add(line, 80, "removes the keys used by SSH")
+	case strings.HasPrefix(text, "no interface "):
+		add(line, 60, "removes the interface and its configuration")
+	case len(fields) >= 3 && fields[0] == "no" && fields[1] == "vlan" && fields[2][0] >= '0' && fields[2][0] <= '9':
+		for _, management := range state.Management {
+			if strings.EqualFold(management, "Vlan"+fields[2]) {
+				add(line, 90, "deletes VLAN %s, the device is managed through %s", fields[2], management)
+				return
+			}
+		}
+		add(line, 60, "deletes VLAN %s, its access ports stop forwarding", fields[2])
+	case strings.HasPrefix(text, "ip route 0.0.0.0 0.0.0.0") || strings.HasPrefix(text, "no ip route 0.0.0.0 0.0.0.0") ||
+		strings.HasPrefix(text, "ip default-gateway") || strings.HasPrefix(text, "no ip default-gateway"):
+		if state.DefaultGateway != "" {
+			add(line, 70, "changes the default route, now via %s", state.DefaultGateway)
+		} else {
+			add(line, 70, "changes the default route")
+		}
+	case text == "no ip routing":
+		add(line, 80, "disables IP routing")
+	case strings.HasPrefix(text, "no router "):
+		add(line, 70, "removes a routing process")
+	case strings.HasPrefix(text, "no spanning-tree vlan") || text == "no spanning-tree":
+		add(line, 80, "disables spanning tree, a loop brings the network down")
+	case strings.HasPrefix(text, "spanning-tree mode"):
+		add(line, 60, "restarts spanning tree on every VLAN")
+	case strings.HasPrefix(text, "vtp mode") || strings.HasPrefix(text, "vtp domain"):
+		add(line, 60, "may replace or spread the VLAN database through VTP")
+	case text == "aaa new-model" || text == "no aaa new-model" || strings.HasPrefix(text, "aaa authentication login") ||
+		strings.HasPrefix(text, "no aaa authentication login"):
+		add(line, 60, "changes how users log in, the current session may be locked out")
+	case strings.HasPrefix(text, "transport input") || strings.HasPrefix(text, "access-class") || strings.HasPrefix(text, "no username"):
+		add(line, 50, "may lock out remote access to the device")
+	}
+}
+
+// PrintRisks shows the risky lines with the policy applied to them
+func PrintRisks(risks []Risk) {
+	for _, risk := range risks {
+		switch {
+		case risk.Score >= RiskBlockScore:
+			fmt.Printf(Red+"  blocked (%d) %s: %s\n"+Reset, risk.Score, risk.Line, risk.Reason)
+		case risk.Score >= RiskConfirmScore:
+			fmt.Printf(Yellow+"  risk (%d) %s: %s\n"+Reset, risk.Score, risk.Line, risk.Reason)
+		default:
+			fmt.Printf(Gray+"  note (%d) %s: %s\n"+Reset, risk.Score, risk.Line, risk.Reason)
+		}
+	}
+}
+
+// riskReport lists the risky lines for the assistant
+func riskReport(risks []Risk) string {
+	var report []string
+	for _, risk := range risks {
+		report = append(report, fmt.Sprintf("risk %d: %s: %s", risk.Score, risk.Line, risk.Reason))
+	}
+	return strings.Join(report, "\n")
+}
+
+// Function returns the risks at or above the score
+func risksAbove(risks []Risk, score int) []Risk {
+	var above []Risk
+	for _, risk := range risks {
+		if risk.Score >= score {
+			above = append(above, risk)
+		}
+	}
+	return above
+}
@@ -0,0 +1,197 @@
+package cisco
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Running-configs archived before each change, as seen from the guestshell.
+// IOS sees the same directory as flash:guest-share/aixedge.
+const (
+	ArchiveDir    = "/flash/guest-share/aixedge"
+	archiveIOSDir = "flash:guest-share/aixedge"
+)
+
+// ConfirmMinutes is the time given to confirm a change before it is rolled back
+var ConfirmMinutes = 5
+
+// NewChangeID returns the ID of a change, also the name of its archive.
+// The random suffix keeps changes and plans made in the same second apart.
+func NewChangeID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+func archivePath(id string) string {
+	return filepath.Join(ArchiveDir, filepath.Base(id)+".cfg")
+}
+
+func markerPath(id string, state string) string {
+	return filepath.Join(ArchiveDir, filepath.Base(id)+"."+state)
+}
+
+// ArchiveRunningConfig saves the running-config before the change is applied
+func ArchiveRunningConfig(id string) error {
+	running, err := RunningConfig()
+	if err != nil {
+		return errors.New("could not read the running-config")
+	}
+	// configure replace does not accept the header of show running-config
+	lines := strings.Split(running, "\n")
+	for len(lines) > 0 && !strings.HasPrefix(lines[0], "!") && !strings.HasPrefix(lines[0], "version") {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return errors.New("the running-config is empty")
+	}
+	// The archive holds the secrets of the running-config, only the user can read it
+	if err := os.MkdirAll(ArchiveDir, 0700); err != nil {
+		return err
+	}
+	// Archive directories made by older versions were readable by everyone
+	if err := os.Chmod(ArchiveDir, 0700); err != nil {
+		return err
+	}
+	// The archive of another change is never replaced, its rollback would restore this one
+	file, err := os.OpenFile(archivePath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("the running-config of change %s is already archived", id)
+		}
+		return err
+	}
+	if _, err := file.WriteString(strings.Join(lines, "\n")); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Rollback replaces the running-config with the one archived before the change.
+// Changes applied after it are reverted too.
+func Rollback(id string) error {
+	if _, err := os.Stat(archivePath(id)); err != nil {
+		return fmt.Errorf("no archived running-config for change %s", id)
+	}
+	cmd := exec.Command("python3", "cmd.py", "-r", archiveIOSDir+"/"+filepath.Base(id)+".cfg")
+	out, err := cmd.Output()
+	if err != nil || !strings.Contains(string(out), "Rollback Done") {
+		return fmt.Errorf("configure replace failed: %s", strings.TrimSpace(string(out)))
+	}
+	os.WriteFile(markerPath(id, "rolledback"), []byte(time.Now().Format(time.RFC3339)), 0600)
+	return nil
+}
+
+// ConfirmChange keeps the change, the rollback timer will leave it in place
+func ConfirmChange(id string) error {
+	if ChangeState(id) == "rolled back" {
+		return fmt.Errorf("change %s was already rolled back", id)
+	}
+	return os.WriteFile(markerPath(id, "confirmed"), []byte(time.Now().Format(time.RFC3339)), 0600)
+}
+
+// ChangeState is "confirmed", "rolled back" or "pending"
+func ChangeState(id string) string {
+	if _, err := os.Stat(markerPath(id, "rolledback")); err == nil {
+		return "rolled back"
+	}
+	if _, err := os.Stat(markerPath(id, "confirmed")); err == nil {
+		return "confirmed"
+	}
+	return "pending"
+}
+
+// Archives returns the IDs of the archived changes, oldest first
+func Archives() []string {
+	files, _ := filepath.Glob(filepath.Join(ArchiveDir, "*.cfg"))
+	var ids []string
+	for _, file := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(file), ".cfg"))
+	}
+	return ids
+}
+
+// StartConfirmTimer starts the process rolling back the change unless it is
+// confirmed in time. It is detached from the terminal so it still runs when
+// the change cuts the session to the device.
+func StartConfirmTimer(id string, minutes int) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	log, err := os.OpenFile(markerPath(id, "log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	cmd := exec.Command(exe, "--rollback-timer", id, strconv.Itoa(minutes))
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// RollbackTimer waits for the confirmation of the change and rolls it back without one
+func RollbackTimer(id string, minutes int) {
+	deadline := time.Now().Add(time.Duration(minutes) * time.Minute)
+	for time.Now().Before(deadline) {
+		if ChangeState(id) != "pending" {
+			return
+		}
+		time.Sleep(5 * time.Second)
+	}
+	if ChangeState(id) != "pending" {
+		return
+	}
+	if err := Rollback(id); err != nil {
+		fmt.Printf("%s change %s not confirmed, rollback failed: %v\n", time.Now().Format(time.RFC3339), id, err)
+		return
+	}
+	fmt.Printf("%s change %s not confirmed, rolled back\n", time.Now().Format(time.RFC3339), id)
+}
+
+// Function asks the user whether the device still works after the change. When the
+// answer does not come in time, e.g. because the uplink was cut, the timer rolls back.
+// verify runs once the timer is started, so a change cutting the device off is still
+// rolled back. It returns the state of the change.
+func confirmApplied(id string, verify func()) string {
+	if err := StartConfirmTimer(id, ConfirmMinutes); err != nil {
+		fmt.Println(Red+"Could not start the rollback timer:", err, Reset)
+		verify()
+		return ChangeState(id)
+	}
+	fmt.Printf(Yellow+"Change %s applied. It is rolled back in %d minutes unless you confirm it.\n"+Reset, id, ConfirmMinutes)
+	verify()
+	if AutoApprove {
+		fmt.Printf("Confirm it with aixedge-rollback --confirm %s\n", id)
+		return ChangeState(id)
+	}
+	if confirm("Does the device work as expected? Keep the change?") {
+		if err := ConfirmChange(id); err != nil {
+			fmt.Println(Red+err.Error(), Reset)
+			return ChangeState(id)
+		}
+		fmt.Println("Change confirmed.")
+		return ChangeState(id)
+	}
+	fmt.Println("Rolling back...")
+	if err := Rollback(id); err != nil {
+		fmt.Println(Red+err.Error(), Reset)
+		return ChangeState(id)
+	}
+	fmt.Println("Change rolled back.")
+	return ChangeState(id)
+}
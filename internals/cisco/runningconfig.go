@@ -0,0 +1,251 @@
+package cisco
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+)
+
+// Largest running-config returned to the assistant, in characters
+const runningConfigCap = 16000
+
+// ConfigLine is a line of IOS configuration with the lines indented below it,
+// e.g. "interface GigabitEthernet1/0/5" with its "switchport access vlan 10".
+type ConfigLine struct {
+	Text     string
+	Children []*ConfigLine
+}
+
+// ParseConfig builds the hierarchy of a configuration from its indentation.
+// Comments, banners of show running-config and "end" are skipped.
+func ParseConfig(text string) []*ConfigLine {
+	type level struct {
+		indent int
+		line   *ConfigLine
+	}
+	var root []*ConfigLine
+	var stack []level
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "!") || trimmed == "end" ||
+			strings.HasPrefix(trimmed, "Building configuration") || strings.HasPrefix(trimmed, "Current configuration") {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		line := &ConfigLine{Text: strings.Join(strings.Fields(trimmed), " ")}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			root = append(root, line)
+		} else {
+			parent := stack[len(stack)-1].line
+			parent.Children = append(parent.Children, line)
+		}
+		stack = append(stack, level{indent, line})
+	}
+	return root
+}
+
+// Commands entering a configuration sub-mode. The lines following them belong to
+// the sub-mode when the configuration proposed by the assistant is not indented.
+var subModes = []string{
+	"interface ", "router ", "vlan ", "line ", "ip access-list ", "ipv6 access-list ",
+	"class-map ", "policy-map ", "route-map ", "key chain ", "tacacs server ", "radius server ",
+	"aaa group server ", "ip dhcp pool ", "vrf definition ", "ip vrf ", "crypto isakmp policy ",
+	"crypto map ", "crypto ipsec profile ", "object-group ", "track ", "spanning-tree mst configuration",
+	"control-plane", "archive", "snmp-server view ", "flow record ", "flow exporter ", "flow monitor ",
+}
+
+// Commands of the configure terminal session itself, not part of the configuration
+var sessionCommands = []string{"configure terminal", "conf t", "config t", "end", "write memory", "wr", "copy running-config startup-config"}
+
+// IsSubMode tells whether the command enters a configuration sub-mode
+func IsSubMode(command string) bool {
+	command = strings.ToLower(command)
+	for _, mode := range subModes {
+		if strings.HasPrefix(command, mode) || command == strings.TrimSpace(mode) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProposedConfig parses configuration suggested by the assistant. Without
+// indentation the sub-mode commands are nested from the sub-mode commands and "exit".
+func ParseProposedConfig(text string) []*ConfigLine {
+	var kept []string
+	indented := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		command := strings.ToLower(strings.Join(strings.Fields(line), " "))
+		if command == "" || isInList(sessionCommands, command) {
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			indented = true
+		}
+		kept = append(kept, line)
+	}
+	if indented {
+		var lines []string
+		for _, line := range kept {
+			if strings.TrimSpace(line) != "exit" {
+				lines = append(lines, line)
+			}
+		}
+		return ParseConfig(strings.Join(lines, "\n"))
+	}
+
+	var root []*ConfigLine
+	var mode *ConfigLine
+	for _, raw := range kept {
+		line := &ConfigLine{Text: strings.Join(strings.Fields(raw), " ")}
+		switch {
+		case line.Text == "exit" || line.Text == "!":
+			mode = nil
+		case IsSubMode(line.Text):
+			root = append(root, line)
+			mode = line
+		case mode != nil:
+			mode.Children = append(mode.Children, line)
+		default:
+			root = append(root, line)
+		}
+	}
+	return root
+}
+
+func isInList(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Full interface names, in the order abbreviations are resolved
+var interfaceTypes = []string{
+	"GigabitEthernet", "TenGigabitEthernet", "TwoGigabitEthernet", "TwentyFiveGigE", "FiveGigabitEthernet",
+	"FortyGigabitEthernet", "HundredGigE", "FastEthernet", "Ethernet", "AppGigabitEthernet",
+	"Port-channel", "Loopback", "Vlan", "Tunnel", "Cellular", "Dialer", "BDI", "Serial",
+}
+
+// ExpandInterface turns an abbreviated interface name (Gi1/0/5, po1) into the
+// name used by the running-config (GigabitEthernet1/0/5, Port-channel1)
+func ExpandInterface(name string) string {
+	name = strings.ReplaceAll(name, " ", "")
+	i := strings.IndexAny(name, "0123456789")
+	if i <= 0 {
+		return name
+	}
+	prefix := strings.ToLower(name[:i])
+	for _, full := range interfaceTypes {
+		if strings.HasPrefix(strings.ToLower(full), prefix) {
+			return full + name[i:]
+		}
+	}
+	return name
+}
+
+// normalize makes two configuration lines comparable
+func normalize(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 2 && fields[0] == "interface" {
+		return "interface " + ExpandInterface(fields[1])
+	}
+	return strings.Join(fields, " ")
+}
+
+// RenderConfig writes the configuration back, one space of indentation per level
+func RenderConfig(lines []*ConfigLine) string {
+	var out strings.Builder
+	var render func(lines []*ConfigLine, depth int)
+	render = func(lines []*ConfigLine, depth int) {
+		for _, line := range lines {
+			out.WriteString(strings.Repeat(" ", depth) + line.Text + "\n")
+			render(line.Children, depth+1)
+		}
+	}
+	render(lines, 0)
+	return out.String()
+}
+
+// RunningConfig returns the running configuration of the device
+func RunningConfig() (string, error) {
+	iosxe := IOSXE{}
+	return iosxe.Command("show running-config")
+}
+
+// Show_running_config returns the running-config, or only the part selected by
+// one of the arguments. Secrets are redacted and the output is capped.
+func Show_running_config(iface string, router string, acl string, section string) string {
+	var config string
+	var err error
+	iosxe := IOSXE{}
+	switch {
+	case iface != "":
+		// The device expands abbreviated interface names
+		config, err = iosxe.Command("show running-config interface " + strings.ReplaceAll(iface, " ", ""))
+	default:
+		config, err = RunningConfig()
+	}
+	if err != nil {
+		return "Could not read the running-config from the device."
+	}
+
+	blocks := ParseConfig(config)
+	switch {
+	case router != "":
+		blocks = filterConfig(blocks, func(fields []string) bool {
+			return len(fields) > 1 && fields[0] == "router" && strings.HasPrefix(strings.Join(fields[1:], " ")+" ", strings.ToLower(router)+" ")
+		})
+	case acl != "":
+		blocks = filterConfig(blocks, func(fields []string) bool {
+			return isACL(fields, strings.ToLower(acl))
+		})
+	case section != "":
+		blocks = filterConfig(blocks, func(fields []string) bool {
+			return strings.Contains(strings.Join(fields, " "), strings.ToLower(section))
+		})
+	}
+	if len(blocks) == 0 {
+		return "No matching configuration."
+	}
+	return capConfig(redact.Text(RenderConfig(blocks)))
+}
+
+// filterConfig keeps the top level blocks whose first line matches, like "| section"
+func filterConfig(blocks []*ConfigLine, match func(fields []string) bool) []*ConfigLine {
+	var kept []*ConfigLine
+	for _, block := range blocks {
+		if match(strings.Fields(strings.ToLower(block.Text))) {
+			kept = append(kept, block)
+		}
+	}
+	return kept
+}
+
+// Named ACLs ("ip access-list extended WEB", "ipv6 access-list V6") and numbered ones ("access-list 101 permit ...")
+func isACL(fields []string, name string) bool {
+	switch {
+	case len(fields) >= 3 && fields[0] == "access-list":
+		return fields[1] == name
+	case len(fields) >= 4 && fields[0] == "ip" && fields[1] == "access-list":
+		return fields[3] == name
+	case len(fields) >= 3 && fields[0] == "ipv6" && fields[1] == "access-list":
+		return fields[2] == name
+	}
+	return false
+}
+
+// capConfig cuts the configuration on a line boundary
+func capConfig(config string) string {
+	if len(config) <= runningConfigCap {
+		return config
+	}
+	cut := strings.LastIndex(config[:runningConfigCap], "\n") + 1
+	remaining := strings.Count(config[cut:], "\n")
+	return config[:cut] + fmt.Sprintf("... %d more lines not shown. Ask for an interface, a routing process, an access list or a section.\n", remaining)
+}
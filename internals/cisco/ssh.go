@@ -0,0 +1,132 @@
+package cisco
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Runner is anything that can run a show command and return its output.
+// IOSXE runs commands locally through cmd.py, SSH runs them on a remote device.
+type Runner interface {
+	Command(command string) (string, error)
+}
+
+// SSH runs commands on a remote IOS-XE device. Every command is executed
+// in its own session, as IOS-XE closes the channel after each exec.
+type SSH struct {
+	Address  string
+	Port     int
+	Username string
+	Password string
+	// Pinned host key, "ssh-ed25519 AAAA..." or its "SHA256:..." fingerprint.
+	// Without it the key must be in KnownHosts, ~/.ssh/known_hosts by default.
+	HostKey    string
+	KnownHosts string
+	Timeout    time.Duration
+}
+
+// HostKeyCallback verifies the key of the device against the pinned key, or
+// against the known_hosts file. An unknown device is refused: the password
+// would otherwise be sent to whoever answers on the address.
+func HostKeyCallback(hostKey string, knownHostsFile string) (ssh.HostKeyCallback, error) {
+	hostKey = strings.TrimSpace(hostKey)
+	switch {
+	case strings.HasPrefix(hostKey, "SHA256:"):
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if ssh.FingerprintSHA256(key) != hostKey {
+				return fmt.Errorf("host key of %s is %s, expected %s", hostname, ssh.FingerprintSHA256(key), hostKey)
+			}
+			return nil
+		}, nil
+	case hostKey != "":
+		pinned, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host_key: %v", err)
+		}
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if !bytes.Equal(key.Marshal(), pinned.Marshal()) {
+				return fmt.Errorf("host key of %s is %s, expected %s", hostname, ssh.FingerprintSHA256(key), ssh.FingerprintSHA256(pinned))
+			}
+			return nil
+		}, nil
+	}
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.New("no host_key and no known_hosts file to verify the device")
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("no host_key and known_hosts can not be read: %v", err)
+	}
+	return callback, nil
+}
+
+func (s *SSH) Command(command string) (string, error) {
+	port := s.Port
+	if port == 0 {
+		port = 22
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	hostKeyCallback, err := HostKeyCallback(s.HostKey, s.KnownHosts)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", s.Address, err)
+	}
+	config := &ssh.ClientConfig{
+		User: s.Username,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(s.Password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = s.Password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(s.Address, strconv.Itoa(port)), config)
+	if err != nil {
+		return "", fmt.Errorf("connecting to %s: %v", s.Address, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("opening session on %s: %v", s.Address, err)
+	}
+	defer session.Close()
+
+	out, err := session.CombinedOutput(command)
+	if err != nil {
+		return "", fmt.Errorf("running '%s' on %s: %v", command, s.Address, err)
+	}
+	return string(out), nil
+}
+
+// Commands runs the given commands one after another on the remote device.
+func (s *SSH) Commands(commands []string) []CommandOutput {
+	results := make([]CommandOutput, len(commands))
+	for i, command := range commands {
+		out, err := s.Command(command)
+		results[i] = CommandOutput{Command: command, Output: out, Err: err}
+	}
+	return results
+}
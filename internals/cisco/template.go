@@ -0,0 +1,213 @@
+package cisco
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Configuration templates for repetitive tasks, e.g. an access port. The body is
+// a Go text/template using the variables as {{.port}}, rendered with aixedge-template.
+const TemplateDir = "templates"
+
+// ConfigTemplate is a template of the library
+type ConfigTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Variable names with what they stand for
+	Variables map[string]string `json:"variables"`
+	Body      string            `json:"template"`
+}
+
+var (
+	templateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	variableName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// Functions available in the templates
+var templateFuncs = template.FuncMap{
+	"expand": ExpandInterface,
+	"split":  strings.Split,
+	"trim":   strings.TrimSpace,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+}
+
+func templatePath(name string) string {
+	return filepath.Join(TemplateDir, name+".json")
+}
+
+// parse checks the template and returns it ready to render
+func (t ConfigTemplate) parse() (*template.Template, error) {
+	if !templateName.MatchString(t.Name) {
+		return nil, fmt.Errorf("'%s' is not a valid template name, use lower case letters, digits, - and _", t.Name)
+	}
+	for name := range t.Variables {
+		if !variableName.MatchString(name) {
+			return nil, fmt.Errorf("'%s' is not a valid variable name", name)
+		}
+	}
+	return template.New(t.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(t.Body)
+}
+
+// Render fills the template with the values, every variable must be given
+func (t ConfigTemplate) Render(values map[string]string) (string, error) {
+	tmpl, err := t.parse()
+	if err != nil {
+		return "", err
+	}
+	var missing, unknown []string
+	for name := range t.Variables {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for name := range values {
+		if _, ok := t.Variables[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// SaveTemplate adds the template to the library. It must render with every declared
+// variable set, so a template using an undeclared variable is refused. An existing
+// template is never overwritten, it has to be deleted first.
+func SaveTemplate(t ConfigTemplate) error {
+	sample := map[string]string{}
+	for name := range t.Variables {
+		sample[name] = "1"
+	}
+	if _, err := t.Render(sample); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(TemplateDir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(templatePath(t.Name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("template %s already exists, delete it first with aixedge-template delete %s", t.Name, t.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadTemplate reads a template of the library
+func LoadTemplate(name string) (ConfigTemplate, error) {
+	var t ConfigTemplate
+	if !templateName.MatchString(name) {
+		return t, fmt.Errorf("'%s' is not a valid template name", name)
+	}
+	b, err := os.ReadFile(templatePath(name))
+	if err != nil {
+		return t, fmt.Errorf("template %s not found", name)
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, fmt.Errorf("%s: %v", templatePath(name), err)
+	}
+	return t, nil
+}
+
+// DeleteTemplate removes a template from the library
+func DeleteTemplate(name string) error {
+	if !templateName.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid template name", name)
+	}
+	if err := os.Remove(templatePath(name)); err != nil {
+		return fmt.Errorf("template %s not found", name)
+	}
+	return nil
+}
+
+// Templates returns the templates of the library, sorted by name
+func Templates() []ConfigTemplate {
+	files, _ := filepath.Glob(filepath.Join(TemplateDir, "*.json"))
+	var templates []ConfigTemplate
+	for _, file := range files {
+		if t, err := LoadTemplate(strings.TrimSuffix(filepath.Base(file), ".json")); err == nil {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
+
+// parseVariables reads "port: the access interface, vlan: the access VLAN"
+func parseVariables(text string) (map[string]string, error) {
+	variables := map[string]string{}
+	for _, item := range strings.Split(text, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		name, description, _ := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		if !variableName.MatchString(name) {
+			return nil, fmt.Errorf("'%s' is not a valid variable name", name)
+		}
+		variables[name] = strings.TrimSpace(description)
+	}
+	if len(variables) == 0 {
+		return nil, errors.New("the template has no variables")
+	}
+	return variables, nil
+}
+
+// Save_config_template is the chat tool saving a template written by the assistant
+func Save_config_template(name string, description string, variables string, body string) string {
+	vars, err := parseVariables(variables)
+	if err != nil {
+		return "The template was not saved: " + err.Error()
+	}
+	t := ConfigTemplate{Name: name, Description: description, Variables: vars, Body: body}
+	if err := SaveTemplate(t); err != nil {
+		return "The template was not saved: " + err.Error()
+	}
+	fmt.Printf(Green+"Template %s saved to %s\n"+Reset, name, templatePath(name))
+	return fmt.Sprintf("The template %s was saved. The user applies it with: aixedge-template apply %s%s", name, name, usageVars(t))
+}
+
+// usageVars returns the --var arguments of the template
+func usageVars(t ConfigTemplate) string {
+	var names []string
+	for name := range t.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var usage strings.Builder
+	for _, name := range names {
+		usage.WriteString(" --var " + name + "=<" + name + ">")
+	}
+	return usage.String()
+}
+
+// TemplateUsage returns the command applying the template
+func TemplateUsage(t ConfigTemplate) string {
+	return "aixedge-template apply " + t.Name + usageVars(t)
+}
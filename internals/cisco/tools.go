@@ -0,0 +1,380 @@
+package cisco
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+/////////////////////////////////////////
+// THIS SECTION IS FOR TOOL DEFINITION //
+/////////////////////////////////////////
+
+// Every chat tool is declared once in Registry. The OpenAI and Gemini
+// schemas are generated from it and tool calls of both providers are
+// validated and dispatched through CallTool.
+
+type ParamType string
+
+const (
+	StringParam  ParamType = "string"
+	IntegerParam ParamType = "integer"
+)
+
+type Param struct {
+	Name        string
+	Type        ParamType
+	Description string
+	Required    bool
+	// Check validates the value, after it was converted to a string
+	Check func(value string) error
+}
+
+// Safety tells whether a tool can run concurrently with other tools
+type Safety int
+
+const (
+	// ReadOnly tools only read from the device
+	ReadOnly Safety = iota
+	// Exclusive tools interact with the user or change the device
+	Exclusive
+)
+
+// Args are the validated arguments of a tool call
+type Args map[string]string
+
+type Tool struct {
+	Name        string
+	Description string
+	Params      []Param
+	Safety      Safety
+	// Changes tools modify the device or its files, the model may only call them when the user allows it
+	Changes bool
+	Handler func(args Args) string
+}
+
+var Registry = []Tool{
+	{
+		Name:        "Show_cdp",
+		Description: "Get information about what devices are connected to this device. Has information about neighbouring devices.",
+		Handler:     func(Args) string { return Show_cdp() },
+	},
+	{
+		Name:        "Show_ip_route",
+		Description: "Get information about what IPv4/IPv6 routes are defined. Routes from EIGRP, OSPF, Static routes and default gateway and many others.",
+		Handler:     func(Args) string { return Show_ip_route() },
+	},
+	{
+		Name:        "Show_ip_int_br",
+		Description: "Get a summary of the status of the interfaces. It gives info about the status of the interface, ip address and others.",
+		Handler:     func(Args) string { return Show_ip_int_br() },
+	},
+	{
+		Name:        "Show_vlan",
+		Description: "Tells what vlans are configured on the device, their name and on which interfaces are applied",
+		Handler:     func(Args) string { return Show_vlan() },
+	},
+	{
+		Name:        "Show_stp",
+		Description: "Tells information about Spanning Tree Protocol or STP. How it is configured and other details.",
+		Handler:     func(Args) string { return Show_stp() },
+	},
+	{
+		Name:        "Show_mac_address",
+		Description: "Tells what mac addresses are seen by each port of the device and other information. This is the mac address table of the device",
+		Handler:     func(Args) string { return Show_mac_address() },
+	},
+	{
+		Name:        "Show_arp",
+		Description: "Tells information about MAC address and IP bindings and on which interface is present",
+		Handler:     func(Args) string { return Show_arp() },
+	},
+	{
+		Name:        "Show_stack",
+		Description: "Tells which members compose the StackWise stack: member number, role, product ID, serial number, software version and installed modules of each member",
+		Handler:     func(Args) string { return Show_stack() },
+	},
+	{
+		Name:        "Run_show_command",
+		Description: "Runs any IOS-XE show command on the device and returns its output. Use it when no other tool gives the needed information, e.g. 'show interfaces status' or 'show ip ospf neighbor'.",
+		Params: []Param{
+			{Name: "command", Type: StringParam, Description: "The complete show command, starting with 'show'", Required: true, Check: checkShowCommand},
+		},
+		Handler: func(args Args) string { return Run_show_command(args["command"]) },
+	},
+	{
+		Name:        "Show_interface",
+		Description: "Tells the detailed status and counters of one interface: errors, speed, duplex, description, line protocol.",
+		Params: []Param{
+			{Name: "interface", Type: StringParam, Description: "The interface name, e.g. GigabitEthernet1/0/5, Gi1/0/5, Vlan10 or Port-channel1", Required: true, Check: checkInterface},
+		},
+		Handler: func(args Args) string { return Show_interface(args["interface"]) },
+	},
+	{
+		Name:        "Show_vlan_id",
+		Description: "Tells the name, status and ports of one VLAN.",
+		Params: []Param{
+			{Name: "vlan_id", Type: IntegerParam, Description: "The VLAN ID, between 1 and 4094", Required: true, Check: checkVlanID},
+		},
+		Handler: func(args Args) string { return Show_vlan_id(args["vlan_id"]) },
+	},
+	{
+		Name:        "Show_ip_route_prefix",
+		Description: "Tells which route the device uses for one IPv4/IPv6 address or prefix: next hop, outgoing interface, protocol and metric.",
+		Params: []Param{
+			{Name: "prefix", Type: StringParam, Description: "An IP address or a prefix in CIDR notation, e.g. 10.1.1.0/24", Required: true, Check: checkPrefix},
+		},
+		Handler: func(args Args) string { return Show_ip_route_prefix(args["prefix"]) },
+	},
+	{
+		Name:        "Show_running_config",
+		Description: "Returns the running configuration of the device. Use it to explain the current configuration or to write incremental configuration. Select a part with one argument, without argument the whole configuration is returned, cut if too long. Secrets are replaced by placeholders like <SECRET-1>.",
+		Params: []Param{
+			{Name: "interface", Type: StringParam, Description: "Only the configuration of this interface, e.g. GigabitEthernet1/0/5", Check: checkInterface},
+			{Name: "router", Type: StringParam, Description: "Only this routing process, e.g. 'ospf 1', 'bgp 65000' or 'eigrp'", Check: checkConfigFilter},
+			{Name: "acl", Type: StringParam, Description: "Only this access list, by name or number", Check: checkConfigFilter},
+			{Name: "section", Type: StringParam, Description: "Only the sections whose first line contains this text, e.g. 'snmp-server', 'vlan' or 'line vty'", Check: checkConfigFilter},
+		},
+		Handler: func(args Args) string {
+			return Show_running_config(args["interface"], args["router"], args["acl"], args["section"])
+		},
+	},
+	{
+		Name: "Save_config_template",
+		Description: "Saves a parameterised configuration snippet to the local template library, for repetitive tasks such as an access port, a voice VLAN or an 802.1X port. " +
+			"Only call it when the user asks for a template, an existing template is not replaced. The body is a Go text/template using the variables as {{.name}}, e.g. 'interface {{.port}}\n switchport access vlan {{.vlan}}'.",
+		Params: []Param{
+			{Name: "name", Type: StringParam, Description: "Name of the template, lower case letters, digits, - and _, e.g. access-port", Required: true},
+			{Name: "description", Type: StringParam, Description: "What the template configures", Required: true},
+			{Name: "variables", Type: StringParam, Description: "The variables with their meaning, comma separated, e.g. 'port: the access interface, vlan: the access VLAN ID'", Required: true},
+			{Name: "template", Type: StringParam, Description: "The configuration lines as a Go text/template", Required: true},
+		},
+		Safety:  Exclusive,
+		Changes: true,
+		Handler: func(args Args) string {
+			return Save_config_template(args["name"], args["description"], args["variables"], args["template"])
+		},
+	},
+	{
+		Name:        "ReviewConfig",
+		Description: "This function start the process to apply configuration or commands to the device. Also helps to review the commands in order to apply them",
+		Safety:      Exclusive,
+		Changes:     true,
+		Handler:     func(Args) string { return ReviewConfig() },
+	},
+}
+
+func LookupTool(name string) (Tool, bool) {
+	for _, tool := range Registry {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// IsExclusiveTool tells whether the tool must not run concurrently with other tools
+func IsExclusiveTool(name string) bool {
+	tool, ok := LookupTool(name)
+	return !ok || tool.Safety == Exclusive
+}
+
+// IsChangeTool tells whether the tool modifies the device
+func IsChangeTool(name string) bool {
+	tool, ok := LookupTool(name)
+	return ok && tool.Changes
+}
+
+// CallTool validates the arguments given by the model (the decoded JSON
+// arguments of OpenAI or the Args of a Gemini FunctionCall) and runs the tool.
+func CallTool(name string, arguments map[string]any) (string, error) {
+	tool, ok := LookupTool(name)
+	if !ok {
+		return "", fmt.Errorf("function '%s' not found", name)
+	}
+	args, err := tool.Validate(arguments)
+	if err != nil {
+		return "", err
+	}
+	return tool.Handler(args), nil
+}
+
+// Validate checks the arguments against the declared parameters and converts them to strings
+func (t Tool) Validate(arguments map[string]any) (Args, error) {
+	args := Args{}
+	for name := range arguments {
+		if _, ok := t.param(name); !ok {
+			return nil, fmt.Errorf("unknown argument '%s' for function '%s'", name, t.Name)
+		}
+	}
+	for _, p := range t.Params {
+		value, ok := arguments[p.Name]
+		if !ok || value == nil {
+			if p.Required {
+				return nil, fmt.Errorf("missing argument '%s' for function '%s'", p.Name, t.Name)
+			}
+			continue
+		}
+		s, err := p.convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("argument '%s' of function '%s': %v", p.Name, t.Name, err)
+		}
+		if p.Check != nil {
+			if err := p.Check(s); err != nil {
+				return nil, fmt.Errorf("argument '%s' of function '%s': %v", p.Name, t.Name, err)
+			}
+		}
+		args[p.Name] = s
+	}
+	return args, nil
+}
+
+func (t Tool) param(name string) (Param, bool) {
+	for _, p := range t.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// JSON numbers are decoded as float64, some models also quote integers
+func (p Param) convert(value any) (string, error) {
+	switch p.Type {
+	case IntegerParam:
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return "", fmt.Errorf("%v is not an integer", v)
+			}
+			return strconv.FormatInt(int64(v), 10), nil
+		case int:
+			return strconv.Itoa(v), nil
+		case int32:
+			return strconv.Itoa(int(v)), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case string:
+			if _, err := strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return "", fmt.Errorf("'%s' is not an integer", v)
+			}
+			return strings.TrimSpace(v), nil
+		}
+		return "", fmt.Errorf("%v is not an integer", value)
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", value)
+		}
+		return strings.TrimSpace(v), nil
+	}
+}
+
+// OpenAITools returns the FunctionDefinition of every tool
+func OpenAITools() []openai.Tool {
+	var tools []openai.Tool
+	for _, tool := range Registry {
+		params := jsonschema.Definition{
+			Type:       jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{},
+		}
+		for _, p := range tool.Params {
+			t := jsonschema.String
+			if p.Type == IntegerParam {
+				t = jsonschema.Integer
+			}
+			params.Properties[p.Name] = jsonschema.Definition{Type: t, Description: p.Description}
+			if p.Required {
+				params.Required = append(params.Required, p.Name)
+			}
+		}
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  params,
+			},
+		})
+	}
+	return tools
+}
+
+// GeminiTools returns the FunctionDeclaration of every tool
+func GeminiTools() *genai.Tool {
+	declarations := []*genai.FunctionDeclaration{}
+	for _, tool := range Registry {
+		params := &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: map[string]*genai.Schema{},
+		}
+		for _, p := range tool.Params {
+			t := genai.TypeString
+			if p.Type == IntegerParam {
+				t = genai.TypeInteger
+			}
+			params.Properties[p.Name] = &genai.Schema{Type: t, Description: p.Description}
+			if p.Required {
+				params.Required = append(params.Required, p.Name)
+			}
+		}
+		declarations = append(declarations, &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  params,
+		})
+	}
+	return &genai.Tool{FunctionDeclarations: declarations}
+}
+
+//////////////////////////////
+// ARGUMENT VALIDATION      //
+//////////////////////////////
+
+func checkShowCommand(command string) error {
+	if !IsValidShowCommand(strings.Join(strings.Fields(command), " ")) {
+		return fmt.Errorf("the command '%s' is not allowed", command)
+	}
+	return nil
+}
+
+func checkConfigFilter(filter string) error {
+	if filter == "" || len(filter) > 64 || strings.ContainsAny(filter, "\n\r;|") {
+		return fmt.Errorf("'%s' is not a valid configuration filter", filter)
+	}
+	return nil
+}
+
+var interfaceName = regexp.MustCompile(`^[A-Za-z-]+[0-9]+(/[0-9]+)*(\.[0-9]+)?$`)
+
+func checkInterface(name string) error {
+	if !interfaceName.MatchString(strings.ReplaceAll(name, " ", "")) {
+		return fmt.Errorf("'%s' is not a valid interface name", name)
+	}
+	return nil
+}
+
+func checkVlanID(vlan string) error {
+	id, err := strconv.Atoi(vlan)
+	if err != nil || id < 1 || id > 4094 {
+		return fmt.Errorf("'%s' is not a valid VLAN ID", vlan)
+	}
+	return nil
+}
+
+func checkPrefix(prefix string) error {
+	if _, _, err := net.ParseCIDR(prefix); err == nil {
+		return nil
+	}
+	if net.ParseIP(prefix) == nil {
+		return fmt.Errorf("'%s' is not a valid IP address or prefix", prefix)
+	}
+	return nil
+}
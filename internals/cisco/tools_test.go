@@ -0,0 +1,213 @@
+package cisco
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		tool      string
+		arguments map[string]any
+		want      Args
+		err       string
+	}{
+		// Tools without parameters
+		{tool: "Show_cdp", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_ip_route", arguments: nil, want: Args{}},
+		{tool: "Show_ip_int_br", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_vlan", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_stp", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_mac_address", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_arp", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_stack", arguments: map[string]any{}, want: Args{}},
+		{tool: "ReviewConfig", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_cdp", arguments: map[string]any{"detail": true}, err: "unknown argument 'detail'"},
+
+		// Run_show_command
+		{tool: "Run_show_command", arguments: map[string]any{"command": " show  version "}, want: Args{"command": "show  version"}},
+		{tool: "Run_show_command", arguments: map[string]any{"command": "show run | include vlan"}, want: Args{"command": "show run | include vlan"}},
+		{tool: "Run_show_command", arguments: map[string]any{}, err: "missing argument 'command'"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": nil}, err: "missing argument 'command'"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": "reload"}, err: "is not allowed"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": "show tech"}, err: "is not allowed"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": "show run | redirect flash:x"}, err: "is not allowed"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": "show clock; reload"}, err: "is not allowed"},
+		{tool: "Run_show_command", arguments: map[string]any{"command": 5.0}, err: "is not a string"},
+
+		// Show_interface
+		{tool: "Show_interface", arguments: map[string]any{"interface": "GigabitEthernet1/0/5"}, want: Args{"interface": "GigabitEthernet1/0/5"}},
+		{tool: "Show_interface", arguments: map[string]any{"interface": "Gi 1/0/5"}, want: Args{"interface": "Gi 1/0/5"}},
+		{tool: "Show_interface", arguments: map[string]any{"interface": "Port-channel1"}, want: Args{"interface": "Port-channel1"}},
+		{tool: "Show_interface", arguments: map[string]any{"interface": "Gi1/0/1.100"}, want: Args{"interface": "Gi1/0/1.100"}},
+		{tool: "Show_interface", arguments: map[string]any{"interface": "Gi1/0/1 | include x"}, err: "not a valid interface name"},
+		{tool: "Show_interface", arguments: map[string]any{"interface": "1/0/1"}, err: "not a valid interface name"},
+		{tool: "Show_interface", arguments: map[string]any{}, err: "missing argument 'interface'"},
+
+		// Show_vlan_id, integers are decoded as float64 from JSON and sometimes quoted
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": 10.0}, want: Args{"vlan_id": "10"}},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": "20"}, want: Args{"vlan_id": "20"}},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": int32(30)}, want: Args{"vlan_id": "30"}},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": int64(4094)}, want: Args{"vlan_id": "4094"}},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": 10.5}, err: "is not an integer"},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": "ten"}, err: "is not an integer"},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": true}, err: "is not an integer"},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": 0.0}, err: "not a valid VLAN ID"},
+		{tool: "Show_vlan_id", arguments: map[string]any{"vlan_id": 4095.0}, err: "not a valid VLAN ID"},
+
+		// Show_ip_route_prefix
+		{tool: "Show_ip_route_prefix", arguments: map[string]any{"prefix": "10.1.1.0/24"}, want: Args{"prefix": "10.1.1.0/24"}},
+		{tool: "Show_ip_route_prefix", arguments: map[string]any{"prefix": "10.1.1.1"}, want: Args{"prefix": "10.1.1.1"}},
+		{tool: "Show_ip_route_prefix", arguments: map[string]any{"prefix": "10.1.1.0/33"}, err: "not a valid IP address or prefix"},
+		{tool: "Show_ip_route_prefix", arguments: map[string]any{"prefix": "default"}, err: "not a valid IP address or prefix"},
+
+		// Show_running_config, every filter is optional
+		{tool: "Show_running_config", arguments: map[string]any{}, want: Args{}},
+		{tool: "Show_running_config", arguments: map[string]any{"interface": "Vlan10", "section": "line vty"}, want: Args{"interface": "Vlan10", "section": "line vty"}},
+		{tool: "Show_running_config", arguments: map[string]any{"router": "ospf 1"}, want: Args{"router": "ospf 1"}},
+		{tool: "Show_running_config", arguments: map[string]any{"acl": "MGMT"}, want: Args{"acl": "MGMT"}},
+		{tool: "Show_running_config", arguments: map[string]any{"section": "snmp | redirect flash:x"}, err: "not a valid configuration filter"},
+		{tool: "Show_running_config", arguments: map[string]any{"acl": ""}, err: "not a valid configuration filter"},
+		{tool: "Show_running_config", arguments: map[string]any{"router": strings.Repeat("x", 65)}, err: "not a valid configuration filter"},
+
+		// Save_config_template
+		{tool: "Save_config_template", arguments: map[string]any{"name": "access-port", "description": "Access port", "variables": "port: the port", "template": "interface {{.port}}"},
+			want: Args{"name": "access-port", "description": "Access port", "variables": "port: the port", "template": "interface {{.port}}"}},
+		{tool: "Save_config_template", arguments: map[string]any{"name": "access-port", "description": "Access port", "variables": "port: the port"}, err: "missing argument 'template'"},
+	}
+	for _, test := range tests {
+		tool, ok := LookupTool(test.tool)
+		if !ok {
+			t.Fatalf("tool %s is not in the registry", test.tool)
+		}
+		got, err := tool.Validate(test.arguments)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s.Validate(%v) error = %v, want %q", test.tool, test.arguments, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s.Validate(%v): %v", test.tool, test.arguments, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s.Validate(%v) = %v, want %v", test.tool, test.arguments, got, test.want)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	names := map[string]bool{}
+	for _, tool := range Registry {
+		if names[tool.Name] {
+			t.Errorf("tool %s is declared twice", tool.Name)
+		}
+		names[tool.Name] = true
+		if tool.Description == "" || tool.Handler == nil {
+			t.Errorf("tool %s has no description or no handler", tool.Name)
+		}
+		if tool.Changes && tool.Safety != Exclusive {
+			t.Errorf("tool %s changes the device but may run concurrently", tool.Name)
+		}
+	}
+	if !IsChangeTool("ReviewConfig") || IsChangeTool("Show_vlan") {
+		t.Error("IsChangeTool does not follow the registry")
+	}
+	if !IsExclusiveTool("ReviewConfig") || IsExclusiveTool("Show_vlan") || !IsExclusiveTool("Unknown") {
+		t.Error("IsExclusiveTool does not follow the registry")
+	}
+}
+
+func TestCallToolErrors(t *testing.T) {
+	if _, err := CallTool("Show_everything", nil); err == nil {
+		t.Error("CallTool of an unknown tool returned no error")
+	}
+	// The handler is not run when the arguments are invalid
+	if _, err := CallTool("Run_show_command", map[string]any{"command": "reload"}); err == nil {
+		t.Error("CallTool with an invalid argument returned no error")
+	}
+}
+
+// Function returns the name, type, description and required flag of the declared parameters
+func declaredParams(tool Tool) (map[string][2]string, []string) {
+	params := map[string][2]string{}
+	var required []string
+	for _, p := range tool.Params {
+		params[p.Name] = [2]string{string(p.Type), p.Description}
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	sort.Strings(required)
+	return params, required
+}
+
+func TestOpenAITools(t *testing.T) {
+	tools := OpenAITools()
+	if len(tools) != len(Registry) {
+		t.Fatalf("OpenAITools returned %d tools, the registry has %d", len(tools), len(Registry))
+	}
+	for i, tool := range tools {
+		declared := Registry[i]
+		if tool.Type != "function" || tool.Function.Name != declared.Name || tool.Function.Description != declared.Description {
+			t.Errorf("tool %d is %s, want %s", i, tool.Function.Name, declared.Name)
+			continue
+		}
+		schema, ok := tool.Function.Parameters.(jsonschema.Definition)
+		if !ok || schema.Type != jsonschema.Object {
+			t.Errorf("%s: parameters are not an object schema", declared.Name)
+			continue
+		}
+		params, required := declaredParams(declared)
+		got := map[string][2]string{}
+		for name, property := range schema.Properties {
+			got[name] = [2]string{string(property.Type), property.Description}
+		}
+		if !reflect.DeepEqual(got, params) {
+			t.Errorf("%s: properties = %v, want %v", declared.Name, got, params)
+		}
+		gotRequired := append([]string(nil), schema.Required...)
+		sort.Strings(gotRequired)
+		if !reflect.DeepEqual(gotRequired, required) {
+			t.Errorf("%s: required = %v, want %v", declared.Name, gotRequired, required)
+		}
+	}
+}
+
+func TestGeminiTools(t *testing.T) {
+	declarations := GeminiTools().FunctionDeclarations
+	if len(declarations) != len(Registry) {
+		t.Fatalf("GeminiTools returned %d declarations, the registry has %d", len(declarations), len(Registry))
+	}
+	types := map[genai.Type]string{genai.TypeString: "string", genai.TypeInteger: "integer"}
+	for i, declaration := range declarations {
+		declared := Registry[i]
+		if declaration.Name != declared.Name || declaration.Description != declared.Description {
+			t.Errorf("declaration %d is %s, want %s", i, declaration.Name, declared.Name)
+			continue
+		}
+		if declaration.Parameters == nil || declaration.Parameters.Type != genai.TypeObject {
+			t.Errorf("%s: parameters are not an object schema", declared.Name)
+			continue
+		}
+		params, required := declaredParams(declared)
+		got := map[string][2]string{}
+		for name, property := range declaration.Parameters.Properties {
+			got[name] = [2]string{types[property.Type], property.Description}
+		}
+		if !reflect.DeepEqual(got, params) {
+			t.Errorf("%s: properties = %v, want %v", declared.Name, got, params)
+		}
+		gotRequired := append([]string(nil), declaration.Parameters.Required...)
+		sort.Strings(gotRequired)
+		if !reflect.DeepEqual(gotRequired, required) {
+			t.Errorf("%s: required = %v, want %v", declared.Name, gotRequired, required)
+		}
+	}
+}
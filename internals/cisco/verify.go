@@ -0,0 +1,175 @@
+package cisco
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Largest number of show commands run to verify a change
+const maxVerifyCommands = 8
+
+// Verification tells whether an applied change does what the user asked for
+type Verification struct {
+	// "success", "partial", "failed" or "unknown"
+	Verdict  string   `json:"verdict"`
+	Summary  string   `json:"summary"`
+	FollowUp string   `json:"follow_up,omitempty"`
+	Commands []string `json:"commands"`
+	// Applied lines not found in the running-config afterwards
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Verify asks the model whether the outputs collected after the change show
+// that the question was answered. It is set by the chat, without it only the
+// running-config is checked.
+var Verify func(question string, config string, outputs string) (Verification, error)
+
+// VerifyCommands chooses the show commands that verify each section of the configuration
+func VerifyCommands(config string) []string {
+	var commands []string
+	add := func(command string) {
+		if len(commands) < maxVerifyCommands && !isInList(commands, command) && IsValidShowCommand(command) {
+			commands = append(commands, command)
+		}
+	}
+	for _, line := range ParseProposedConfig(config) {
+		fields := strings.Fields(line.Text)
+		lower := strings.Fields(strings.ToLower(line.Text))
+		if len(lower) > 0 && lower[0] == "no" {
+			fields, lower = fields[1:], lower[1:]
+		}
+		switch {
+		case len(lower) >= 3 && lower[0] == "interface" && lower[1] == "range":
+			add("show interfaces status")
+		case len(lower) == 2 && lower[0] == "interface":
+			iface := ExpandInterface(fields[1])
+			add("show running-config interface " + iface)
+			if hasChild(line, "switchport") {
+				add("show interfaces " + iface + " switchport")
+			} else {
+				add("show ip interface brief " + iface)
+			}
+		case len(lower) == 2 && lower[0] == "vlan" && checkVlanID(lower[1]) == nil:
+			add("show vlan id " + lower[1])
+		case len(lower) >= 2 && lower[0] == "router" && lower[1] == "ospf":
+			add("show ip ospf neighbor")
+		case len(lower) >= 2 && lower[0] == "router" && lower[1] == "bgp":
+			add("show ip bgp summary")
+		case len(lower) >= 2 && lower[0] == "router" && lower[1] == "eigrp":
+			add("show ip eigrp neighbors")
+		case len(lower) >= 3 && lower[0] == "ip" && lower[1] == "route" && lower[2] != "vrf":
+			add("show ip route " + lower[2])
+		case len(lower) >= 3 && lower[0] == "ip" && lower[1] == "access-list":
+			add("show ip access-lists " + fields[len(fields)-1])
+		case len(lower) >= 4 && lower[0] == "ip" && lower[1] == "dhcp" && lower[2] == "pool":
+			add("show ip dhcp pool " + fields[3])
+		case len(lower) >= 1 && lower[0] == "spanning-tree":
+			add("show spanning-tree summary")
+		case len(lower) >= 2 && lower[0] == "ntp" && lower[1] == "server":
+			add("show ntp associations")
+		}
+	}
+	return commands
+}
+
+func hasChild(line *ConfigLine, command string) bool {
+	for _, child := range line.Children {
+		if hasCommand([]string{command}, child.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingLines returns the applied lines the running-config does not show.
+// The device may also have rewritten them, e.g. abbreviated commands.
+func missingLines(config string, running string) []string {
+	var missing []string
+	var walk func(diff []*DiffLine, parent string)
+	walk = func(diff []*DiffLine, parent string) {
+		for _, d := range diff {
+			switch d.Status {
+			case DiffNew, DiffChanged, DiffRemoved:
+				if parent != "" {
+					missing = append(missing, parent+" / "+d.Text)
+				} else {
+					missing = append(missing, d.Text)
+				}
+			case DiffContext:
+				walk(d.Children, d.Text)
+			}
+		}
+	}
+	walk(DiffConfig(ParseProposedConfig(config), ParseConfig(running)), "")
+	return missing
+}
+
+// verifyChange runs the show commands after the change, asks the model whether the
+// question was answered and stores the verification with the change record
+func verifyChange(record *ChangeRecord) {
+	fmt.Println("\nVerifying the change...")
+	verification := Verification{Verdict: "unknown", Commands: VerifyCommands(record.Edited)}
+
+	var outputs strings.Builder
+	if running, err := RunningConfig(); err == nil {
+		verification.Missing = missingLines(record.Edited, running)
+		if len(verification.Missing) > 0 {
+			outputs.WriteString("Applied lines not found in the running-config:\n" + strings.Join(verification.Missing, "\n") + "\n\n")
+		} else {
+			outputs.WriteString("Every applied line is in the running-config.\n\n")
+		}
+	}
+	iosxe := IOSXE{}
+	for _, result := range iosxe.Commands(verification.Commands) {
+		if result.Err != nil {
+			outputs.WriteString(LabelOutput(result.Command, "The command failed."))
+			continue
+		}
+		outputs.WriteString(LabelOutput(result.Command, result.Output))
+	}
+
+	if Verify != nil {
+		judged, err := Verify(record.Question, record.Edited, outputs.String())
+		if err != nil {
+			fmt.Println("Could not ask the model to verify the change:", err)
+		} else {
+			judged.Commands = verification.Commands
+			judged.Missing = verification.Missing
+			verification = judged
+		}
+	}
+	if verification.Verdict == "unknown" && verification.Summary == "" {
+		if len(verification.Missing) == 0 {
+			verification.Summary = "Every applied line is in the running-config."
+		} else {
+			verification.Summary = fmt.Sprintf("%d applied lines are not in the running-config.", len(verification.Missing))
+		}
+	}
+
+	record.Verification = &verification
+	PrintVerification(verification)
+	if err := UpdateChange(*record); err != nil {
+		fmt.Println("Error writing the change history:", err)
+	}
+}
+
+// PrintVerification shows the verdict, green on success, yellow on partial success, red otherwise
+func PrintVerification(v Verification) {
+	colour := Red
+	switch v.Verdict {
+	case "success":
+		colour = Green
+	case "partial", "unknown":
+		colour = Yellow
+	}
+	fmt.Println(colour + "Verification: " + v.Verdict + Reset)
+	if v.Summary != "" {
+		fmt.Println("  " + v.Summary)
+	}
+	for _, line := range v.Missing {
+		fmt.Println(Gray + "  not in the running-config: " + line + Reset)
+	}
+	if v.FollowUp != "" {
+		fmt.Println(Yellow + "  Suggested follow-up: " + v.FollowUp + Reset)
+	}
+}
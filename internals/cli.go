@@ -6,9 +6,16 @@ var helpText = `
 AI assitant for AIXEdge products.
 	Arguments:
 	aixedge-chat										Chat with the AI assitant
+	aixedge-chat --resume <id>								Continues a previous chat session
+	aixedge-chat --list-sessions								Lists the stored chat sessions
+	aixedge-chat --export <id> [file]							Exports a chat session as markdown
+	aixedge-chat --script <f|-> [--resume <id>] [--allow-apply] [--transcript <f>]	Asks the questions of a file or stdin without a terminal
+	  in the chat: /run /apply /model /clear /save /history /tokens /compact /help
 	aixedge <query>       	 							Queries adressed to AI Assistant
 	aixedge <show command> @ <query to AI assistant> 				AI Assistant helps with command's output
+	aixedge <show cmd>; <show cmd> @ <query to AI assistant> 			AI Assistant helps with several commands' outputs
 	aixedge-pacp <file_name> <query to AI assistant>		AI assistant helps with PCAP interpretation
+	aixedge-fleet [--group <g>] <show cmd> @ <query>				Queries AI Assistant across the devices of .inventory.json
 	aixedge-optics <query>							        Queries AI Assistant regarding optics & compatibility
 	aixedge-feature <query>							         Queries AI Assistant regarding optics & compatibility 
 	aixedge-help  	     								Presents options to run AI assistant
@@ -16,8 +23,16 @@ AI assitant for AIXEdge products.
 	aixedge-cfg <API_KEY>  								Initial config of the script; Adds the API key;
 	aixedge-init									Initialization of AI assistant
 	aixedge-uninstall								Uninstall the AI assistant
+	aixedge-facts [--refresh]							Shows the device facts, --refresh collects them again
 	aixedge-version                                                                 Shows installed version
-	aixedge-history										See what configs have been applied from copilot
+	aixedge-history [list] | show <id> | search <text> | export [file]	See what configs have been applied from copilot
+	aixedge-rollback <change-id>							Restores the running-config saved before a change
+	aixedge-rollback --confirm <change-id> | --list					Keeps a change before its rollback timer ends, lists the changes
+	aixedge-approve [--key file] <plan>						Approves a change plan saved by another engineer
+	aixedge-approve --keygen <name>							Creates the signing key of an engineer
+	aixedge-apply <plan>								Applies an approved change plan
+	aixedge-template list | show <name> | delete <name>				Configuration templates saved from the chat
+	aixedge-template apply <name> --var name=value ...				Renders a template and reviews it before applying
 
 For more information visit: https://github.com/Cisco-AIXEdge/Cisco-AIXEdge
 	`
@@ -10,17 +10,83 @@ import (
 	"strings"
 
 	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
 )
 
 type configFile struct {
-	Engine        string `json:"engine"`
-	EngineVERSION string `json:"engine_version"`
-	Apikey        string `json:"api_key"`
-	PID           string `json:"pid,omitempty"`
-	SerialNumber  string `json:"serialnumber,omitempty"`
-	Eula          bool   `json:"eula"`
-	SwVer         string `json:"swVer"`
-	Platform      string `json:"platform"`
+	Engine        string         `json:"engine"`
+	EngineVERSION string         `json:"engine_version"`
+	Apikey        string         `json:"api_key"`
+	PID           string         `json:"pid,omitempty"`
+	SerialNumber  string         `json:"serialnumber,omitempty"`
+	Eula          bool           `json:"eula"`
+	SwVer         string         `json:"swVer"`
+	Platform      string         `json:"platform"`
+	Backend       *backendConfig `json:"backend,omitempty"`
+	Facts         deviceFacts    `json:"facts"`
+	// Regexes of site specific secrets removed before data is sent to the LLM,
+	// on top of the built-in IOS-XE patterns
+	Redact []string `json:"redact,omitempty"`
+	// Minutes to confirm a change before it is rolled back, 5 by default
+	ConfirmMinutes int `json:"confirm_minutes,omitempty"`
+	// Risk scores from which a line has to be typed again or is blocked, e.g.
+	//	"risk": {"confirm": 50, "block": 90}
+	Risk *riskPolicy `json:"risk,omitempty"`
+	// Changes are saved as plans applied with aixedge-apply once approved
+	// by one of the approvers, named with their public key. The author of a
+	// plan signs it with their own key, which must be registered here too.
+	RequireApproval bool              `json:"require_approval,omitempty"`
+	Approvers       map[string]string `json:"approvers,omitempty"`
+}
+
+type riskPolicy struct {
+	Confirm int `json:"confirm,omitempty"`
+	Block   int `json:"block,omitempty"`
+}
+
+// Optional structured data backend used by the chat tools instead of the CLI.
+//
+//	"backend": {"type": "restconf", "address": "https://127.0.0.1/restconf", "username": "admin", "password_env": "AIXEDGE_YANG_PASS", "insecure": true}
+//	"backend": {"type": "netconf", "address": "127.0.0.1", "port": 830, "username": "admin", "password_env": "AIXEDGE_YANG_PASS", "host_key": "SHA256:..."}
+//
+// NETCONF verifies the host key with host_key, or with known_hosts (~/.ssh/known_hosts by default).
+type backendConfig struct {
+	Type        string `json:"type"`
+	Address     string `json:"address"`
+	Port        int    `json:"port,omitempty"`
+	Username    string `json:"username"`
+	PasswordEnv string `json:"password_env"`
+	Insecure    bool   `json:"insecure,omitempty"`
+	HostKey     string `json:"host_key,omitempty"`
+	KnownHosts  string `json:"known_hosts,omitempty"`
+}
+
+// Function builds the structured backend defined in the configuration, if any
+func (b *backendConfig) backend() (cisco.Backend, error) {
+	if b == nil {
+		return nil, nil
+	}
+	password := os.Getenv(b.PasswordEnv)
+	switch b.Type {
+	case "restconf":
+		return &cisco.RESTCONF{
+			BaseURL:  b.Address,
+			Username: b.Username,
+			Password: password,
+			Insecure: b.Insecure,
+		}, nil
+	case "netconf":
+		return &cisco.NETCONF{
+			Address:    b.Address,
+			Port:       b.Port,
+			Username:   b.Username,
+			Password:   password,
+			HostKey:    b.HostKey,
+			KnownHosts: b.KnownHosts,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported backend: %s", b.Type)
+	}
 }
 
 // Function opens the configuration file
@@ -30,7 +96,22 @@ func (c *Client) configRead() (configFile, error) {
 		return configFile{}, errors.New("No API Key")
 	}
 	defer file.Close()
-	return c.configJSON(file), nil
+	cfg := c.configJSON(file)
+	// Every command reads the configuration before talking to the LLM
+	if err := redact.SetPatterns(cfg.Redact); err != nil {
+		fmt.Println(err)
+	}
+	if cfg.ConfirmMinutes > 0 {
+		cisco.ConfirmMinutes = cfg.ConfirmMinutes
+	}
+	if cfg.Risk != nil && cfg.Risk.Confirm > 0 {
+		cisco.RiskConfirmScore = cfg.Risk.Confirm
+	}
+	if cfg.Risk != nil && cfg.Risk.Block > 0 {
+		cisco.RiskBlockScore = cfg.Risk.Block
+	}
+	cisco.RequireApproval = cfg.RequireApproval
+	return cfg, nil
 }
 
 // Function writes SN, PN, API key into .config.json
@@ -46,18 +127,25 @@ func (c *Client) ConfigWrite(provider string, model string, api string) {
 	// Download python module
 	// c.getCLI("cmd.py")
 
-	var cfgJson string
-	var err error
 	cfg := &configFile{}
 	cfg.Engine = provider
 	cfg.EngineVERSION = model
 	cfg.Apikey = api
 	cfg.Eula = true
-	iosxe := cisco.IOSXE{}
-	cfg.PID, cfg.SerialNumber, cfg.SwVer, cfg.Platform, err = iosxe.Device()
+	// Settings that are not given on the command line are kept from the previous configuration
+	if previous, err := c.configRead(); err == nil {
+		cfg.Backend = previous.Backend
+		cfg.Redact = previous.Redact
+		cfg.ConfirmMinutes = previous.ConfirmMinutes
+		cfg.Risk = previous.Risk
+		cfg.RequireApproval = previous.RequireApproval
+		cfg.Approvers = previous.Approvers
+	}
+	facts, swVer, err := collectFacts()
 	if err != nil {
 		panic(err)
 	}
+	cfg.setFacts(facts, swVer)
 
 	// Check if the provider and model are valid
 	if _, err := validateProvider(provider); err != nil {
@@ -68,15 +156,7 @@ func (c *Client) ConfigWrite(provider string, model string, api string) {
 		panic(err)
 	}
 
-	if _, err := os.Create(".config.json"); err != nil {
-		panic(err)
-	}
-	if b, err := json.MarshalIndent(cfg, "", "\t"); err == nil {
-		cfgJson = string(b)
-	} else {
-		panic(err)
-	}
-	if err := os.WriteFile(".config.json", []byte(cfgJson), 0644); err != nil {
+	if err := c.configSave(*cfg); err != nil {
 		panic(err)
 	}
 	fmt.Println("AIXEdge configured")
@@ -121,5 +201,3 @@ func (c *Client) getCLI(file string) {
 	url = fmt.Sprintf("%v%v/%v", c.SoftwareURL, latestVersion, file)
 	c.download(url, file)
 }
-
-
@@ -0,0 +1,222 @@
+package internals
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+)
+
+// Facts are refreshed when older than this or when the software version changes,
+// aixedge-facts --refresh collects them at once
+const factsTTL = 24 * time.Hour
+
+// Facts about the device cached in .config.json
+type deviceFacts struct {
+	Version      string              `json:"version,omitempty"`
+	Platform     string              `json:"platform,omitempty"`
+	PID          string              `json:"pid,omitempty"`
+	SerialNumber string              `json:"serialnumber,omitempty"`
+	Inventory    []string            `json:"inventory,omitempty"`
+	LicenseLevel string              `json:"license_level,omitempty"`
+	Uptime       string              `json:"uptime,omitempty"`
+	Stack        []cisco.StackMember `json:"stack,omitempty"`
+	CollectedAt  time.Time           `json:"collected_at"`
+}
+
+// Same PIDs as the ones cmd.py -i keeps from show inventory
+var inventoryPID = regexp.MustCompile(`ISR|IR|C8|C9|NM`)
+
+// Function returns the PIDs of every stack member and of their network modules
+func (f deviceFacts) pids() []string {
+	var pids []string
+	for _, member := range f.Stack {
+		for _, pid := range append([]string{member.PID}, member.Modules...) {
+			if inventoryPID.MatchString(pid) && !isInList(pids, pid) {
+				pids = append(pids, pid)
+			}
+		}
+	}
+	if len(pids) == 0 {
+		return f.Inventory
+	}
+	return pids
+}
+
+// Function describes the stack members in one line for the prompts
+func (f deviceFacts) describe() string {
+	var members []string
+	for _, member := range f.Stack {
+		members = append(members, fmt.Sprintf("member %d %s (%s)", member.Member, member.PID, member.Role))
+	}
+	return strings.Join(members, ", ")
+}
+
+// Function gives the context of the device to the chat assistant
+func (f deviceFacts) context() string {
+	if f.PID == "" {
+		return ""
+	}
+	text := fmt.Sprintf("The device is a %s", f.PID)
+	if f.Platform != "" {
+		text += fmt.Sprintf(" (%s)", f.Platform)
+	}
+	if f.Version != "" {
+		text += " running IOS-XE " + f.Version
+	}
+	if f.LicenseLevel != "" {
+		text += " with license level " + f.LicenseLevel
+	}
+	if len(f.Stack) > 1 {
+		text += ". It is a stack of " + f.describe()
+	}
+	return text + "."
+}
+
+// Function identifies the device in the change history
+func (cfg configFile) device() cisco.DeviceInfo {
+	device := cisco.DeviceInfo{
+		PID:          cfg.Facts.PID,
+		SerialNumber: cfg.Facts.SerialNumber,
+		Platform:     cfg.Facts.Platform,
+		Version:      cfg.Facts.Version,
+	}
+	if device.PID == "" {
+		device.PID, device.SerialNumber, device.Platform, device.Version = cfg.PID, cfg.SerialNumber, cfg.Platform, cfg.SwVer
+	}
+	return device
+}
+
+func (f deviceFacts) expired() bool {
+	return time.Since(f.CollectedAt) > factsTTL
+}
+
+// Function collects every fact from the device.
+// Only the PID/SN/version from cmd.py -d are mandatory.
+func collectFacts() (deviceFacts, string, error) {
+	var facts deviceFacts
+	var swVer string
+	var err error
+	iosxe := cisco.IOSXE{}
+	facts.PID, facts.SerialNumber, swVer, facts.Platform, err = iosxe.Device()
+	if err != nil {
+		return facts, "", err
+	}
+	if version, err := iosxe.Version(); err == nil {
+		facts.Version = version.Version
+		facts.Uptime = version.Uptime
+		facts.LicenseLevel = version.LicenseLevel
+	} else {
+		fmt.Println("Could not collect version facts:", err)
+	}
+	// Stack members are optional facts, older cmd.py versions don't report them
+	if facts.Stack, err = iosxe.Stack(); err != nil {
+		fmt.Println("Could not collect stack members:", err)
+	}
+	if inventory, err := iosxe.Inventory(); err == nil {
+		for _, pid := range strings.Split(strings.TrimSpace(inventory), ",") {
+			if pid != "" {
+				facts.Inventory = append(facts.Inventory, pid)
+			}
+		}
+	}
+	facts.CollectedAt = time.Now()
+	return facts, swVer, nil
+}
+
+// Function stores freshly collected facts in the configuration
+func (cfg *configFile) setFacts(facts deviceFacts, swVer string) {
+	cfg.Facts = facts
+	cfg.PID = facts.PID
+	cfg.SerialNumber = facts.SerialNumber
+	cfg.SwVer = swVer
+	cfg.Platform = facts.Platform
+}
+
+var versionLine = regexp.MustCompile(`Cisco IOS XE Software, Version\s+(\S+)`)
+
+// Software version read once per process, so the facts of an upgraded device are not
+// used until the TTL expires
+var probedVersion string
+
+// Function reads the software version with a single filtered show version
+func probeVersion() (string, error) {
+	if probedVersion != "" {
+		return probedVersion, nil
+	}
+	iosxe := cisco.IOSXE{}
+	out, err := iosxe.Command("show version | include Cisco IOS XE Software")
+	if err != nil {
+		return "", err
+	}
+	match := versionLine.FindStringSubmatch(out)
+	if match == nil {
+		return "", fmt.Errorf("no software version in %q", strings.TrimSpace(out))
+	}
+	probedVersion = match[1]
+	return probedVersion, nil
+}
+
+// Function refreshes the cached facts when they expired or when the device runs
+// another software version than the cached one, e.g. after an upgrade. With force
+// the facts are always collected again.
+func (c *Client) refreshFacts(cfg *configFile, force bool) error {
+	if !force && !cfg.Facts.expired() {
+		version, err := probeVersion()
+		if err != nil || version == cfg.Facts.Version {
+			return nil
+		}
+		fmt.Printf("Software version changed from %s to %s, refreshing device facts\n", cfg.Facts.Version, version)
+	}
+	facts, swVer, err := collectFacts()
+	if err != nil {
+		return err
+	}
+	cfg.setFacts(facts, swVer)
+	return c.configSave(*cfg)
+}
+
+// Function writes the configuration into .config.json
+func (c *Client) configSave(cfg configFile) error {
+	b, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(".config.json", b, 0644)
+}
+
+// Facts shows the cached device facts.
+// Usage: aixedge-facts [--refresh]
+func (c *Client) Facts(args []string) {
+	flags := flag.NewFlagSet("aixedge-facts", flag.ContinueOnError)
+	refresh := flags.Bool("refresh", false, "collect the facts from the device again")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	cfg, err := c.configRead()
+	if err != nil {
+		fmt.Println("AIXEdge is not configured. Please do aixedge-cfg <LLM Provider> <Model> <API KEY>")
+		return
+	}
+	if err := c.refreshFacts(&cfg, *refresh); err != nil {
+		fmt.Println("Missing/Corrupted dependecy! Python module is not able to collect device facts")
+		return
+	}
+	f := cfg.Facts
+	fmt.Printf("Platform:      %s\n", f.Platform)
+	fmt.Printf("PID:           %s\n", f.PID)
+	fmt.Printf("Serial number: %s\n", f.SerialNumber)
+	fmt.Printf("Version:       %s\n", f.Version)
+	fmt.Printf("License level: %s\n", f.LicenseLevel)
+	fmt.Printf("Uptime:        %s\n", f.Uptime)
+	fmt.Printf("Inventory:     %s\n", strings.Join(f.Inventory, ", "))
+	for _, member := range f.Stack {
+		fmt.Printf("Member %d:      %s %s %s %s %s\n", member.Member, member.Role, member.PID, member.SN, member.Version, strings.Join(member.Modules, " "))
+	}
+	fmt.Printf("Collected at:  %s (refreshed every %s)\n", f.CollectedAt.Format(time.RFC1123), factsTTL)
+}
@@ -201,6 +201,9 @@ func (c *Client) FeaturePrompt(content string) {
 	if err != nil {
 		panic(err)
 	}
+	if err := c.refreshFacts(&cfg, false); err != nil {
+		fmt.Println("Could not refresh device facts:", err)
+	}
 
 	switch cfg.Engine {
 	case "openai":
@@ -0,0 +1,297 @@
+package internals
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/providers"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
+)
+
+const defaultInventoryFile = ".inventory.json"
+
+// Inventory of devices used by aixedge-fleet.
+// Passwords are never stored in the inventory: a credential only references
+// the environment variable that holds the password. Host keys are verified with
+// the host_key of the host, or with known_hosts (~/.ssh/known_hosts by default).
+//
+//	{
+//	  "known_hosts": "/bootflash/guest-share/known_hosts",
+//	  "credentials": {"lab": {"username": "admin", "password_env": "AIXEDGE_LAB_PASS"}},
+//	  "hosts": [{"name": "acc-sw1", "address": "10.0.0.11", "groups": ["access"], "credentials": "lab",
+//	             "host_key": "SHA256:..."}]
+//	}
+type inventoryFile struct {
+	KnownHosts  string                `json:"known_hosts,omitempty"`
+	Credentials map[string]credential `json:"credentials"`
+	Hosts       []inventoryHost       `json:"hosts"`
+}
+
+type credential struct {
+	Username    string `json:"username"`
+	PasswordEnv string `json:"password_env"`
+}
+
+type inventoryHost struct {
+	Name        string   `json:"name"`
+	Address     string   `json:"address"`
+	Port        int      `json:"port,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+	Credentials string   `json:"credentials"`
+	HostKey     string   `json:"host_key,omitempty"`
+}
+
+type fleetResult struct {
+	Name    string   `json:"name"`
+	Address string   `json:"address"`
+	Groups  []string `json:"groups,omitempty"`
+	Output  string   `json:"output,omitempty"`
+	Answer  string   `json:"answer,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type fleetReport struct {
+	Question  string        `json:"question"`
+	Commands  []string      `json:"commands"`
+	Group     string        `json:"group,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	Devices   []fleetResult `json:"devices"`
+	Summary   string        `json:"summary"`
+}
+
+// Function reads the inventory file
+func readInventory(path string) (inventoryFile, error) {
+	inv := inventoryFile{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inv, fmt.Errorf("error reading inventory %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return inv, fmt.Errorf("error parsing inventory %s: %v", path, err)
+	}
+	return inv, nil
+}
+
+// Function returns the hosts belonging to the group. An empty group selects every host.
+func (inv inventoryFile) selectHosts(group string) []inventoryHost {
+	if group == "" {
+		return inv.Hosts
+	}
+	var hosts []inventoryHost
+	for _, host := range inv.Hosts {
+		for _, g := range host.Groups {
+			if g == group {
+				hosts = append(hosts, host)
+				break
+			}
+		}
+	}
+	return hosts
+}
+
+// Function builds the SSH runner of a host from its credential reference
+func (inv inventoryFile) runner(host inventoryHost) (*cisco.SSH, error) {
+	cred, ok := inv.Credentials[host.Credentials]
+	if !ok {
+		return nil, fmt.Errorf("unknown credentials '%s'", host.Credentials)
+	}
+	password := os.Getenv(cred.PasswordEnv)
+	if password == "" {
+		return nil, fmt.Errorf("environment variable %s is empty", cred.PasswordEnv)
+	}
+	return &cisco.SSH{
+		Address:    host.Address,
+		Port:       host.Port,
+		Username:   cred.Username,
+		Password:   password,
+		HostKey:    host.HostKey,
+		KnownHosts: inv.KnownHosts,
+	}, nil
+}
+
+// Fleet runs show commands and a question against every device of the inventory.
+// Usage: aixedge-fleet [--inventory f] [--group g] [--workers n] [--json f] [--md f] <show cmd>; ... @ <question>
+func (c *Client) Fleet(args []string) {
+	flags := flag.NewFlagSet("aixedge-fleet", flag.ContinueOnError)
+	inventoryPath := flags.String("inventory", defaultInventoryFile, "inventory file")
+	group := flags.String("group", "", "only run against hosts of this group")
+	workers := flags.Int("workers", 5, "number of devices queried in parallel")
+	jsonPath := flags.String("json", "", "export the results as JSON")
+	mdPath := flags.String("md", "", "export the results as markdown")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	commands, question := providers.SplitPrompt(strings.Join(flags.Args(), " "))
+	if len(commands) == 0 || question == "" {
+		fmt.Println("Usage: aixedge-fleet [--group <group>] <show command>; <show command> @ <question>")
+		return
+	}
+	for _, command := range commands {
+		if !cisco.IsValidShowCommand(command) {
+			fmt.Printf("The command '%s' is not supported yet. :)\n", command)
+			return
+		}
+	}
+
+	cfg, err := c.configRead()
+	if err != nil {
+		fmt.Println("API key non-existent. Please do aixedge-cfg <LLM Provider> <Model> <API KEY>")
+		return
+	}
+	a := providers.Client{
+		API: cfg.Apikey,
+		Engine: providers.Engine{
+			Provider: cfg.Engine,
+			Version:  cfg.EngineVERSION,
+		},
+	}
+
+	inv, err := readInventory(*inventoryPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	hosts := inv.selectHosts(*group)
+	if len(hosts) == 0 {
+		fmt.Println("No hosts selected from the inventory")
+		return
+	}
+
+	report := fleetReport{
+		Question:  question,
+		Commands:  commands,
+		Group:     *group,
+		Timestamp: time.Now(),
+		Devices:   runFleet(inv, hosts, commands, question, &a, *workers),
+	}
+	report.Summary, err = fleetSummary(report, &a)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending to LLM (%s): %v\n", a.Engine.Provider, err)
+	}
+
+	fmt.Print(report.markdown())
+
+	if *jsonPath != "" {
+		if b, err := json.MarshalIndent(report, "", "\t"); err == nil {
+			err = os.WriteFile(*jsonPath, b, 0644)
+			if err != nil {
+				fmt.Println("Error writing JSON report:", err)
+			}
+		}
+	}
+	if *mdPath != "" {
+		if err := os.WriteFile(*mdPath, []byte(report.markdown()), 0644); err != nil {
+			fmt.Println("Error writing markdown report:", err)
+		}
+	}
+}
+
+// runFleet queries the hosts with a bounded pool of workers.
+// Results keep the order of the inventory.
+func runFleet(inv inventoryFile, hosts []inventoryHost, commands []string, question string, a *providers.Client, workers int) []fleetResult {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]fleetResult, len(hosts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = queryHost(inv, hosts[i], commands, question, a)
+			}
+		}()
+	}
+	for i := range hosts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// queryHost collects the outputs from one device and asks the LLM about them
+func queryHost(inv inventoryFile, host inventoryHost, commands []string, question string, a *providers.Client) fleetResult {
+	result := fleetResult{Name: host.Name, Address: host.Address, Groups: host.Groups}
+	runner, err := inv.runner(host)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var output strings.Builder
+	for _, out := range runner.Commands(commands) {
+		if out.Err != nil {
+			result.Error = out.Err.Error()
+			return result
+		}
+		output.WriteString(cisco.LabelOutput(out.Command, out.Output))
+	}
+	result.Output = output.String()
+
+	prompt := fmt.Sprintf(`
+You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE.
+%s
+Answer the following question for device %s: %s
+
+%s`, untrusted.Instructions, host.Name, question, untrusted.Wrap(host.Name, result.Output))
+	result.Answer, err = sendToLLM(prompt, a)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// fleetSummary asks the LLM for an aggregated answer over every device
+func fleetSummary(report fleetReport, a *providers.Client) (string, error) {
+	var answers strings.Builder
+	for _, device := range report.Devices {
+		if device.Error != "" {
+			answers.WriteString(fmt.Sprintf("Device %s: not reachable (%s)\n\n", device.Name, device.Error))
+			continue
+		}
+		// The answers were derived from device data
+		answers.WriteString(untrusted.Wrap("answer for device "+device.Name, device.Answer) + "\n\n")
+	}
+	if answers.Len() == 0 {
+		return "", errors.New("no device answered")
+	}
+	prompt := fmt.Sprintf(`
+You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE.
+%s
+Below are the answers per device to the question: %s
+Give an aggregated answer for the whole fleet, naming the devices concerned.
+
+%s`, untrusted.Instructions, report.Question, answers.String())
+	return sendToLLM(prompt, a)
+}
+
+func (r fleetReport) markdown() string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# Fleet report: %s\n\n", r.Question))
+	md.WriteString(fmt.Sprintf("- Commands: `%s`\n", strings.Join(r.Commands, "; ")))
+	if r.Group != "" {
+		md.WriteString(fmt.Sprintf("- Group: %s\n", r.Group))
+	}
+	md.WriteString(fmt.Sprintf("- Time: %s\n\n", r.Timestamp.Format(time.RFC3339)))
+	md.WriteString("## Summary\n\n")
+	md.WriteString(r.Summary + "\n\n")
+	md.WriteString("## Devices\n\n")
+	for _, device := range r.Devices {
+		md.WriteString(fmt.Sprintf("### %s (%s)\n\n", device.Name, device.Address))
+		if device.Error != "" {
+			md.WriteString(fmt.Sprintf("Error: %s\n\n", device.Error))
+			continue
+		}
+		md.WriteString(device.Answer + "\n\n")
+	}
+	return md.String()
+}
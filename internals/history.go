@@ -0,0 +1,148 @@
+package internals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+)
+
+// History shows the changes applied from the assistant.
+// Usage: aixedge-history [list] | show <id> | search <text> | export [file.md|file.json]
+func (c *Client) History(args []string) {
+	records, err := cisco.LoadHistory()
+	if err != nil {
+		fmt.Println("Error reading the change history:", err)
+		if len(records) == 0 {
+			return
+		}
+	}
+	if len(records) == 0 {
+		fmt.Println("No change recorded")
+		return
+	}
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch {
+	case command == "list":
+		listChanges(records)
+	case command == "show" && len(args) == 2:
+		for _, record := range records {
+			if record.ID == args[1] {
+				fmt.Print(changeMarkdown(record))
+				return
+			}
+		}
+		fmt.Printf("Change %s not found\n", args[1])
+	case command == "search" && len(args) >= 2:
+		text := strings.ToLower(strings.Join(args[1:], " "))
+		var found []cisco.ChangeRecord
+		for _, record := range records {
+			if strings.Contains(strings.ToLower(record.Question+"\n"+record.Suggested+"\n"+record.Edited+"\n"+record.Operator), text) {
+				found = append(found, record)
+			}
+		}
+		if len(found) == 0 {
+			fmt.Println("No change found")
+			return
+		}
+		listChanges(found)
+	case command == "export":
+		file := ""
+		if len(args) > 1 {
+			file = args[1]
+		}
+		exportChanges(records, file)
+	default:
+		fmt.Println("Usage: aixedge-history [list] | show <id> | search <text> | export [file.md|file.json]")
+	}
+}
+
+func listChanges(records []cisco.ChangeRecord) {
+	for _, record := range records {
+		question := strings.ReplaceAll(record.Question, "\n", " ")
+		if len(question) > 50 {
+			question = question[:47] + "..."
+		}
+		fmt.Printf("%s\t%s\t%-11s\t%-10s\t%3d lines\t%s\n", record.ID, record.Timestamp.Format("2006-01-02 15:04"),
+			record.State(), record.Operator, len(record.Lines), question)
+	}
+}
+
+// Function writes every change as JSON when the file ends with .json, as markdown otherwise
+func exportChanges(records []cisco.ChangeRecord, file string) {
+	var out string
+	if strings.HasSuffix(file, ".json") {
+		b, err := json.MarshalIndent(records, "", "\t")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		out = string(b) + "\n"
+	} else {
+		var md strings.Builder
+		md.WriteString("# AIXEdge change history\n\n")
+		for _, record := range records {
+			md.WriteString(changeMarkdown(record) + "\n")
+		}
+		out = md.String()
+	}
+	if file == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := os.WriteFile(file, []byte(out), 0600); err != nil {
+		fmt.Println("Error writing file:", err)
+		return
+	}
+	fmt.Printf("%d changes exported to %s\n", len(records), file)
+}
+
+func changeMarkdown(record cisco.ChangeRecord) string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("## Change %s\n\n", record.ID))
+	md.WriteString(fmt.Sprintf("- Date: %s\n", record.Timestamp.Format(time.RFC1123)))
+	md.WriteString(fmt.Sprintf("- State: %s\n", record.State()))
+	md.WriteString(fmt.Sprintf("- Operator: %s\n", record.Operator))
+	md.WriteString(fmt.Sprintf("- Device: %s %s, IOS-XE %s\n", record.Device.PID, record.Device.SerialNumber, record.Device.Version))
+	if record.Model != "" {
+		md.WriteString(fmt.Sprintf("- Model: %s\n", record.Model))
+	}
+	if record.Plan != "" {
+		md.WriteString(fmt.Sprintf("- Plan: %s, approved by %s\n", record.Plan, record.Approver))
+	}
+	if record.SessionID != "" {
+		md.WriteString(fmt.Sprintf("- Chat session: %s\n", record.SessionID))
+	}
+	if record.Question != "" {
+		md.WriteString(fmt.Sprintf("- Question: %s\n", record.Question))
+	}
+	md.WriteString("\n### Suggested\n\n```\n" + strings.TrimRight(record.Suggested, "\n") + "\n```\n\n")
+	if record.Edited != record.Suggested {
+		md.WriteString("### Edited\n\n```\n" + strings.TrimRight(record.Edited, "\n") + "\n```\n\n")
+	}
+	md.WriteString("### Result\n\n")
+	for _, line := range record.Lines {
+		if line.OK {
+			md.WriteString(fmt.Sprintf("- ok `%s`\n", line.Line))
+		} else {
+			md.WriteString(fmt.Sprintf("- FAILED `%s`: %s\n", line.Line, line.Error))
+		}
+	}
+	if v := record.Verification; v != nil {
+		md.WriteString(fmt.Sprintf("\n### Verification: %s\n\n%s\n", v.Verdict, v.Summary))
+		for _, line := range v.Missing {
+			md.WriteString(fmt.Sprintf("- not in the running-config: `%s`\n", line))
+		}
+		if v.FollowUp != "" {
+			md.WriteString("\nSuggested follow-up: " + v.FollowUp + "\n")
+		}
+		md.WriteString("\nCommands: " + strings.Join(v.Commands, ", ") + "\n")
+	}
+	return md.String()
+}
@@ -1,12 +1,58 @@
 package internals
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
 	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/providers"
 )
 
-func (c *Client) Interactive() {
+// Interactive starts the chat with the AI assistant.
+// Usage: aixedge-chat [--resume <id>] | --list-sessions | --export <id> [file.md]
+//
+//	| --script <file|-> [--resume <id>] [--allow-apply] [--transcript <file.md>]
+func (c *Client) Interactive(args []string) {
+	flags := flag.NewFlagSet("aixedge-chat", flag.ContinueOnError)
+	resume := flags.String("resume", "", "resume a stored chat session")
+	list := flags.Bool("list-sessions", false, "list the stored chat sessions")
+	export := flags.String("export", "", "export a chat session as markdown")
+	script := flags.String("script", "", "ask the questions of a file, one per line, '-' reads stdin")
+	allowApply := flags.Bool("allow-apply", false, "let the /apply lines of the script apply configuration")
+	transcript := flags.String("transcript", "", "markdown transcript of the script, default .sessions/<id>.md")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	if *list {
+		listSessions()
+		return
+	}
+	if *export != "" {
+		exportSession(*export, flags.Arg(0))
+		return
+	}
+	var questions []string
+	if *script != "" {
+		var err error
+		if questions, err = readScript(*script); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	var session *providers.Session
+	if *resume != "" {
+		var err error
+		if session, err = providers.LoadSession(*resume); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
 	defer func() {
 		if panicInfo := recover(); panicInfo != nil {
 			fmt.Println("API key non-existent. Please do copilot-cfg <LLM Provider> <Model> <API KEY>")
@@ -16,6 +62,14 @@ func (c *Client) Interactive() {
 	if err != nil {
 		panic(err)
 	}
+	if err := c.refreshFacts(&cfg, false); err != nil {
+		fmt.Println("Could not refresh device facts:", err)
+	}
+	cisco.Change.Device = cfg.device()
+	cisco.StructuredBackend, err = cfg.Backend.backend()
+	if err != nil {
+		fmt.Println(err)
+	}
 	engine := providers.Engine{
 		Provider: cfg.Engine,
 		Version:  cfg.EngineVERSION,
@@ -23,7 +77,94 @@ func (c *Client) Interactive() {
 	a := providers.Client{
 		API:    cfg.Apikey,
 		Engine: engine,
+		Facts:  cfg.Facts.context(),
+	}
+	if *script != "" {
+		batchChat(a, cfg.SerialNumber, session, questions, *allowApply, *transcript)
+		return
 	}
-	a.Interactive(cfg.SerialNumber)
+	a.Interactive(cfg.SerialNumber, session)
 	// c.Interactive_Telemetry()
 }
+
+// Function runs a scripted conversation, e.g. from EEM or cron, and writes its transcript.
+// With --resume the script continues the stored session.
+func batchChat(a providers.Client, sn string, session *providers.Session, questions []string, allowApply bool, transcript string) {
+	session, err := a.Batch(sn, session, questions, allowApply)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if transcript == "" {
+		transcript = filepath.Join(providers.SessionDir, session.ID+".md")
+	}
+	if err := os.MkdirAll(filepath.Dir(transcript), 0755); err != nil {
+		fmt.Println("Error writing transcript:", err)
+		return
+	}
+	if err := os.WriteFile(transcript, []byte(session.Markdown()), 0600); err != nil {
+		fmt.Println("Error writing transcript:", err)
+		return
+	}
+	fmt.Printf("Transcript written to %s\n", transcript)
+}
+
+// Function reads the questions of a script, one per line.
+// Empty lines and lines starting with # are skipped.
+func readScript(path string) ([]string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open script: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+	var questions []string
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		questions = append(questions, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read script: %v", err)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("the script %s has no questions", path)
+	}
+	return questions, nil
+}
+
+func listSessions() {
+	sessions, err := providers.ListSessions()
+	if err != nil || len(sessions) == 0 {
+		fmt.Println("No chat sessions stored")
+		return
+	}
+	for _, session := range sessions {
+		fmt.Printf("%s\t%s\t%-16s\t%s\n", session.ID, session.Updated.Format("2006-01-02 15:04"), session.Model, session.Title())
+	}
+}
+
+// Function writes the session as markdown to the file, or to stdout when no file is given
+func exportSession(id string, file string) {
+	session, err := providers.LoadSession(id)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if file == "" {
+		fmt.Print(session.Markdown())
+		return
+	}
+	if err := os.WriteFile(file, []byte(session.Markdown()), 0600); err != nil {
+		fmt.Println("Error writing file:", err)
+		return
+	}
+	fmt.Printf("Session %s exported to %s\n", id, file)
+}
@@ -330,14 +330,26 @@ func (c *Client) OpticsPrompt(content string) {
 	if err != nil {
 		panic(err)
 	}
-	iosxe := cisco.IOSXE{}
-
-	inventory, _ := iosxe.Inventory()
-
-	data := strings.Split(string(inventory), ",")
-	lastIndex := len(data) - 1
-	data[lastIndex] = strings.TrimRight(data[lastIndex], "\n")
-	INVENTORY = data
+	if err := c.refreshFacts(&cfg, false); err != nil {
+		fmt.Println("Could not refresh device facts:", err)
+	}
+	// Every member of a stack is checked, falling back to show inventory
+	// when the stack facts were not collected at aixedge-cfg time
+	INVENTORY = cfg.Facts.pids()
+	if len(INVENTORY) == 0 {
+		iosxe := cisco.IOSXE{}
+
+		inventory, _ := iosxe.Inventory()
+
+		data := strings.Split(string(inventory), ",")
+		lastIndex := len(data) - 1
+		data[lastIndex] = strings.TrimRight(data[lastIndex], "\n")
+		INVENTORY = data
+	}
+	defaultPID := cfg.PID
+	if len(cfg.Facts.Stack) > 1 {
+		defaultPID = cfg.PID + ". This device is a stack of " + cfg.Facts.describe()
+	}
 
 	modelType := cfg.Engine
 
@@ -350,7 +362,7 @@ func (c *Client) OpticsPrompt(content string) {
 			Properties: map[string]jsonschema.Definition{
 				"query": {
 					Type:        jsonschema.String,
-					Description: "The optic Product ID or Device Product ID e.g. " + cfg.PID + ". Your default value is " + cfg.PID,
+					Description: "The optic Product ID or Device Product ID e.g. " + cfg.PID + ". Your default value is " + defaultPID,
 				},
 			},
 			Required: []string{"query"},
@@ -456,7 +468,7 @@ func (c *Client) OpticsPrompt(content string) {
 			Properties: map[string]*genai.Schema{
 				"query": {
 					Type:        genai.TypeString,
-					Description: "The optic Product ID or Device Product ID e.g. " + cfg.PID + ". Your default value is " + cfg.PID,
+					Description: "The optic Product ID or Device Product ID e.g. " + cfg.PID + ". Your default value is " + defaultPID,
 				},
 			},
 			Required: []string{"query"},
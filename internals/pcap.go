@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/providers"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
 	"github.com/sashabaranov/go-openai"
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -143,10 +145,11 @@ func (c *Client) Pcap(question string) {
 	// Prepare the prompt for LLM
 	prompt := fmt.Sprintf(`
 You have a PCAP summary below please answer the following question: %s
+%s
 
 PCAP Summary:
 %s
-`, question, summary)
+`, question, untrusted.Instructions, untrusted.Wrap("PCAP summary", summary))
 
 	// Send to ChatGPT
 	response, err := sendToLLM(prompt, &a)
@@ -680,6 +683,7 @@ func formatPayload(payload []byte) string {
 
 // Function to send a prompt to the LLM and get a response
 func sendToLLM(prompt string, a *providers.Client) (string, error) {
+	prompt = redact.Text(prompt)
 	switch a.Engine.Provider {
 	case "openai":
 		ctx := context.Background()
@@ -0,0 +1,97 @@
+package internals
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/chzyer/readline"
+)
+
+// Approve signs a change plan made by another engineer.
+// Usage: aixedge-approve [--key file] <plan> | --keygen <name> [--key file]
+func (c *Client) Approve(args []string) {
+	flags := flag.NewFlagSet("aixedge-approve", flag.ContinueOnError)
+	key := flags.String("key", "", "private key file of the approver")
+	keygen := flags.String("keygen", "", "create the key of the named approver")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	keyFile := cisco.SigningKeyFile(*key)
+	switch {
+	case *keygen != "":
+		public, err := cisco.GenerateApproverKey(*keygen, keyFile)
+		if err != nil {
+			fmt.Println("Could not create the key:", err)
+			return
+		}
+		fmt.Printf("Private key written to %s, keep it to yourself.\n", keyFile)
+		fmt.Println("Add the public key to the approvers in .config.json of the devices, it also signs the plans you write:")
+		fmt.Printf("\t\"approvers\": {\"%s\": \"%s\"}\n", *keygen, public)
+	case flags.NArg() == 1:
+		plan, file, err := cisco.LoadPlan(flags.Arg(0))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		cisco.PrintPlan(plan)
+		fmt.Println("\nApprove this plan? (yes/no)")
+		var answer string
+		fmt.Scanln(&answer)
+		if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+			fmt.Println("Plan not approved")
+			return
+		}
+		if err := cisco.ApprovePlan(plan, keyFile); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cisco.WritePlan(plan, file); err != nil {
+			fmt.Println("Error writing the plan:", err)
+			return
+		}
+		fmt.Printf("Plan %s approved by %s\n", plan.ID, plan.Approval.Approver)
+	default:
+		fmt.Println("Usage: aixedge-approve [--key file] <plan> | --keygen <name> [--key file]")
+	}
+}
+
+// ApplyPlan applies a change plan approved with aixedge-approve.
+// Usage: aixedge-apply <plan>
+func (c *Client) ApplyPlan(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: aixedge-apply <plan>")
+		return
+	}
+	cfg, err := c.configRead()
+	if err != nil {
+		fmt.Println("AIXEdge is not configured. Please do copilot-cfg <LLM Provider> <Model> <API KEY>")
+		return
+	}
+	plan, _, err := cisco.LoadPlan(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := cisco.VerifyPlan(plan, cfg.Approvers); err != nil {
+		fmt.Println(cisco.Red+"The plan can not be applied:", err, cisco.Reset)
+		return
+	}
+	cisco.Change.Device = cfg.device()
+	closePrompt := openPrompt()
+	defer closePrompt()
+	fmt.Println(cisco.ApplyPlan(plan))
+}
+
+// Function opens the terminal prompt the configuration review reads from, outside of the chat
+func openPrompt() func() {
+	rl, err := readline.NewEx(&readline.Config{Prompt: "> ", InterruptPrompt: "^C"})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	cisco.Rl = rl
+	return func() { rl.Close() }
+}
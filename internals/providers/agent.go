@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
+)
+
+// Maximum number of model round trips to answer one question
+const maxAgentSteps = 8
+
+// A tool call requested by the model, independent of the provider
+type toolCall struct {
+	ID   string
+	Name string
+	Args map[string]any
+}
+
+type toolResult struct {
+	Call   toolCall
+	Output string
+}
+
+// runToolCalls executes every tool call of a model turn.
+// Read-only tools run concurrently, exclusive tools (e.g. ReviewConfig)
+// run afterwards one at a time. Results keep the order of the calls.
+func (c *chat) runToolCalls(calls []toolCall) []toolResult {
+	results := make([]toolResult, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		if cisco.IsExclusiveTool(call.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, call toolCall) {
+			defer wg.Done()
+			results[i] = runToolCall(call)
+		}(i, call)
+	}
+	wg.Wait()
+	for i, call := range calls {
+		if !cisco.IsExclusiveTool(call.Name) {
+			continue
+		}
+		if cisco.IsChangeTool(call.Name) && !c.permitChange(call) {
+			results[i] = toolResult{Call: call, Output: "The user did not allow " + call.Name + ". Call it only when the user asks for it."}
+			continue
+		}
+		results[i] = runToolCall(call)
+	}
+	return results
+}
+
+// permitChange asks the user every time the model wants to run a tool modifying
+// the device or its files, the wording of the question is not trusted. Device data read by
+// the other tools can then not trigger ReviewConfig on its own. Batch chats have
+// nobody to ask: the script applies configuration with /apply.
+func (c *chat) permitChange(call toolCall) bool {
+	if c.batch {
+		return false
+	}
+	fmt.Printf(cisco.Yellow+"The assistant wants to run %s, which changes the device or its files.\n"+cisco.Reset, call.Name)
+	fmt.Println("Do you want to continue? (yes/no)")
+	answer, _ := cisco.Rl.Readline()
+	return strings.ToLower(strings.TrimSpace(answer)) == "yes"
+}
+
+// Errors are returned to the model as the tool output so it can recover
+func runToolCall(call toolCall) toolResult {
+	output, err := cisco.CallTool(call.Name, call.Args)
+	if err != nil {
+		return toolResult{Call: call, Output: fmt.Sprintf("Error: %v", err)}
+	}
+	if output == "" {
+		output = "The tool returned no output."
+	}
+	return toolResult{Call: call, Output: untrusted.Wrap(call.Name, redact.Text(output))}
+}
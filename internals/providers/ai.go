@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
 	"github.com/google/generative-ai-go/genai"
 	"github.com/sashabaranov/go-openai"
 	"google.golang.org/api/option"
@@ -20,6 +22,8 @@ type Engine struct {
 type Client struct {
 	API    string
 	Engine Engine
+	// Facts describes the device to the chat assistant
+	Facts string
 }
 
 func geminiPrintResponse(resp *genai.GenerateContentResponse) {
@@ -35,27 +39,50 @@ func geminiPrintResponse(resp *genai.GenerateContentResponse) {
 	}
 }
 
-func isValidShowCommand(command string) bool {
-	// Check if the command starts with "show"
-	if !strings.HasPrefix(command, "show") {
-		return false
-	}
+// To separate cisco commands and AI query '@' is used.
+// Several commands can be given, separated by ';'.
+const (
+	promptSeparator  = "@"
+	commandSeparator = ";"
+)
 
-	// Define a blacklist of show commands
-	blacklist := []string{
-		"show tech",
-		"show interfaces",
-		"show tech-support",
+// SplitPrompt separates the show commands from the question.
+// Only the first '@' is a separator, so the question itself may contain '@'.
+func SplitPrompt(content string) ([]string, string) {
+	cmdPart, question, _ := strings.Cut(content, promptSeparator)
+	var commands []string
+	for _, command := range strings.Split(cmdPart, commandSeparator) {
+		command = strings.Join(strings.Fields(command), " ")
+		if command != "" {
+			commands = append(commands, command)
+		}
 	}
+	return commands, strings.TrimSpace(question)
+}
 
-	// Check if the command is in the blacklist
-	for _, blacklistedCommand := range blacklist {
-		if strings.TrimSpace(strings.ToLower(command)) == blacklistedCommand {
-			return false
+// runShowCommands validates and runs the commands in parallel and labels each output
+// so the model knows which command produced it. On failure it prints the reason
+// and returns a non-200 code.
+func runShowCommands(cli cisco.IOSXE, commands []string) (string, int) {
+	if len(commands) == 0 {
+		fmt.Print("No show command given before '@'. :)\n")
+		return "", 402
+	}
+	for _, command := range commands {
+		if !cisco.IsValidShowCommand(command) {
+			fmt.Printf("The command '%s' is not supported yet. :)\n", command)
+			return "", 402
+		}
+	}
+	var output strings.Builder
+	for _, result := range cli.Commands(commands) {
+		if result.Err != nil {
+			fmt.Printf("There is a typo in '%s'. Fix it and try again! :)\n", result.Command)
+			return "", 402
 		}
+		output.WriteString(cisco.LabelOutput(result.Command, result.Output))
 	}
-	// If the command starts with "show" and is not in the blacklist, return true
-	return true
+	return output.String(), 200
 }
 
 // Function handles interaction between app and OpenAI
@@ -64,8 +91,6 @@ func (a *Client) Prompt(content string) (string, string, int) {
 	var cmd string
 	var error_code int
 	error_code = 200
-	//To separate cisco command and AI query '@' is used
-	promptSeparator := "@"
 	cli := cisco.IOSXE{}
 	switch a.Engine.Provider {
 	case "openai":
@@ -76,63 +101,58 @@ func (a *Client) Prompt(content string) (string, string, int) {
 
 		//Based on existance of separator the API call is selected
 		if strings.Contains(content, promptSeparator) {
-			contents := strings.Split(content, promptSeparator)
-			cmd = contents[0]
-			prompt = contents[1]
-			if isValidShowCommand(cmd) {
-				output, err := cli.Command(cmd)
-				if err == nil {
-					resp, err = client.CreateChatCompletion(
-						context.Background(),
-						openai.ChatCompletionRequest{
-							Model:     a.Engine.Version,
-							MaxTokens: maxToken,
-							Messages: []openai.ChatCompletionMessage{
-								{
-									Role:    openai.ChatMessageRoleSystem,
-									Content: "You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE",
-								},
-								{
-									Role:    openai.ChatMessageRoleUser,
-									Content: "You have the following output: " + output,
-								},
-								{
-									Role:    openai.ChatMessageRoleUser,
-									Content: prompt,
-								},
+			commands, question := SplitPrompt(content)
+			cmd = strings.Join(commands, "; ")
+			prompt = redact.Text(question)
+			output, code := runShowCommands(cli, commands)
+			output = untrusted.Wrap(cmd, redact.Text(output))
+			if code == 200 {
+				resp, err = client.CreateChatCompletion(
+					context.Background(),
+					openai.ChatCompletionRequest{
+						Model:     a.Engine.Version,
+						MaxTokens: maxToken,
+						Messages: []openai.ChatCompletionMessage{
+							{
+								Role:    openai.ChatMessageRoleSystem,
+								Content: "You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE. " + untrusted.Instructions,
+							},
+							{
+								Role:    openai.ChatMessageRoleUser,
+								Content: "You have the following output: " + output,
+							},
+							{
+								Role:    openai.ChatMessageRoleUser,
+								Content: prompt,
 							},
 						},
-					)
-					if err != nil {
-						switch {
-						case strings.Contains(err.Error(), "400"):
-							fmt.Print("Copilot cannot understand at this moment this output! :(\n")
-							error_code = 400
-						case strings.Contains(err.Error(), "401"):
-							fmt.Printf("Invalid API key!\nUse aixedge-cfg <LLM Provider> <Model> <API KEY> to update the API key\n")
-
-						case strings.Contains(err.Error(), "429"):
-							fmt.Printf("Copilot has hit limit..Please try again later!")
-							error_code = 429
+					},
+				)
+				if err != nil {
+					switch {
+					case strings.Contains(err.Error(), "400"):
+						fmt.Print("Copilot cannot understand at this moment this output! :(\n")
+						error_code = 400
+					case strings.Contains(err.Error(), "401"):
+						fmt.Printf("Invalid API key!\nUse aixedge-cfg <LLM Provider> <Model> <API KEY> to update the API key\n")
 
-						default:
-							fmt.Printf("Copilot has encountered a server error.")
-							error_code = 500
-						}
+					case strings.Contains(err.Error(), "429"):
+						fmt.Printf("Copilot has hit limit..Please try again later!")
+						error_code = 429
 
-					} else {
-						fmt.Println(resp.Choices[0].Message.Content)
+					default:
+						fmt.Printf("Copilot has encountered a server error.")
+						error_code = 500
 					}
+
 				} else {
-					error_code = 402
-					fmt.Print("There is a typo in you show command. Fix it and try again! :)\n")
+					fmt.Println(resp.Choices[0].Message.Content)
 				}
 			} else {
-				error_code = 402
-				fmt.Print("The command is not supported yet. :)\n")
+				error_code = code
 			}
 		} else {
-			prompt = content
+			prompt = redact.Text(content)
 			resp, err = client.CreateChatCompletion(
 				context.Background(),
 				openai.ChatCompletionRequest{
@@ -189,48 +209,43 @@ func (a *Client) Prompt(content string) (string, string, int) {
 
 		//Based on existance of separator the API call is selected
 		if strings.Contains(content, promptSeparator) {
-			contents := strings.Split(content, promptSeparator)
-			cmd = contents[0]
-			prompt = contents[1]
-			if isValidShowCommand(cmd) {
-				output, err := cli.Command(cmd)
-				if err == nil {
-					model.SystemInstruction = &genai.Content{
-						Parts: []genai.Part{genai.Text(`You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE. You have the following output:
-					` + output)},
-					}
-
-					resp, err := model.GenerateContent(ctx, genai.Text(prompt))
-					if err != nil {
-						switch {
-						case strings.Contains(err.Error(), "400"):
-							fmt.Print("Copilot cannot understand at this moment this output! :(\n")
-							error_code = 400
-						case strings.Contains(err.Error(), "401"):
-							fmt.Printf("Invalid API key!\nUse aixedge-cfg <LLM Provider> <Model> <API KEY> to update the API key\n")
+			commands, question := SplitPrompt(content)
+			cmd = strings.Join(commands, "; ")
+			prompt = redact.Text(question)
+			output, code := runShowCommands(cli, commands)
+			output = untrusted.Wrap(cmd, redact.Text(output))
+			if code == 200 {
+				model.SystemInstruction = &genai.Content{
+					Parts: []genai.Part{genai.Text(`You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE. ` + untrusted.Instructions + ` You have the following output:
+				` + output)},
+				}
 
-						case strings.Contains(err.Error(), "429"):
-							fmt.Printf("Copilot has hit limit..Please try again later!")
-							error_code = 429
+				resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+				if err != nil {
+					switch {
+					case strings.Contains(err.Error(), "400"):
+						fmt.Print("Copilot cannot understand at this moment this output! :(\n")
+						error_code = 400
+					case strings.Contains(err.Error(), "401"):
+						fmt.Printf("Invalid API key!\nUse aixedge-cfg <LLM Provider> <Model> <API KEY> to update the API key\n")
 
-						default:
-							fmt.Printf("Copilot has encountered a server error.")
-							error_code = 500
-						}
+					case strings.Contains(err.Error(), "429"):
+						fmt.Printf("Copilot has hit limit..Please try again later!")
+						error_code = 429
 
-					} else {
-						geminiPrintResponse(resp)
+					default:
+						fmt.Printf("Copilot has encountered a server error.")
+						error_code = 500
 					}
+
 				} else {
-					error_code = 402
-					fmt.Print("There is a typo in you show command. Fix it and try again! :)\n")
+					geminiPrintResponse(resp)
 				}
 			} else {
-				error_code = 402
-				fmt.Print("The command is not supported yet. :)\n")
+				error_code = code
 			}
 		} else {
-			prompt = content
+			prompt = redact.Text(content)
 			model.SystemInstruction = &genai.Content{
 				Parts: []genai.Part{genai.Text(`You are a Cisco network engineer assistant and you respond only to questions about Cisco IOS-XE.`)},
 			}
@@ -0,0 +1,230 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Long troubleshooting sessions are compacted before they reach the context
+// limit of the model: outputs of older tool calls are dropped and, if still
+// needed, the older turns are replaced by a summary. The system prompt (with
+// the device facts), the recent turns and the pending config blocks are kept.
+
+const (
+	// Estimated size of the conversation that triggers the compaction
+	compactThreshold = 12000
+	// Number of recent questions kept verbatim
+	keepTurns = 3
+	// Older tool outputs longer than this are dropped
+	staleToolOutput = 200
+	// Size of a tool output in the transcript given to the summary
+	transcriptToolOutput = 2000
+)
+
+const droppedOutput = "[Output removed to save space. Call the tool again if it is needed.]"
+
+const summaryPrompt = "Summarise the following conversation between a network engineer and an IOS-XE assistant. " +
+	"Keep the problem being worked on, the findings from the device (interfaces, addresses, VLANs, routes, neighbours), " +
+	"the decisions taken and the configuration that was proposed or applied. Be concise.\n\n"
+
+// estimateTokens approximates the size of the conversation sent to the
+// model, counting about four characters per token
+func (c *chat) estimateTokens() int {
+	chars := 0
+	if c.openai != nil {
+		for _, msg := range c.req.Messages {
+			chars += len(msg.Content)
+			for _, tc := range msg.ToolCalls {
+				chars += len(tc.Function.Name) + len(tc.Function.Arguments)
+			}
+		}
+	} else {
+		for _, msg := range c.history {
+			chars += len(msg.Content)
+			for _, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Args)
+				chars += len(tc.Name) + len(args)
+			}
+		}
+	}
+	return chars / 4
+}
+
+// compact shrinks the conversation. With force the older turns are
+// summarised even when dropping the tool outputs was enough.
+func (c *chat) compact(force bool) {
+	before := c.estimateTokens()
+	var err error
+	if c.openai != nil {
+		err = c.compactOpenAI(force)
+	} else {
+		err = c.compactGemini(force)
+	}
+	if err != nil {
+		fmt.Println("Could not compact the conversation:", err)
+		return
+	}
+	c.sync()
+	fmt.Printf("Conversation compacted from about %d to %d tokens\n", before, c.estimateTokens())
+}
+
+// recentStart returns the index of the first message of the recent turns
+func recentStart(count int, isQuestion func(i int) bool) int {
+	turns := 0
+	for i := count - 1; i >= 0; i-- {
+		if isQuestion(i) {
+			turns++
+			if turns == keepTurns {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+func (c *chat) compactOpenAI(force bool) error {
+	messages := c.req.Messages
+	split := recentStart(len(messages), func(i int) bool {
+		return messages[i].Role == openai.ChatMessageRoleUser
+	})
+	// messages[0] is the system prompt
+	if split <= 1 {
+		return nil
+	}
+
+	// The tool messages stay so every tool call keeps its answer
+	for i := 1; i < split; i++ {
+		if messages[i].Role == openai.ChatMessageRoleTool && len(messages[i].Content) > staleToolOutput {
+			messages[i].Content = droppedOutput
+		}
+	}
+	if !force && c.estimateTokens() <= compactThreshold {
+		return nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range messages[1:split] {
+		switch msg.Role {
+		case openai.ChatMessageRoleUser:
+			transcript.WriteString("User: " + msg.Content + "\n")
+		case openai.ChatMessageRoleAssistant:
+			for _, tc := range msg.ToolCalls {
+				transcript.WriteString(fmt.Sprintf("Assistant called %s %s\n", tc.Function.Name, tc.Function.Arguments))
+			}
+			if msg.Content != "" {
+				transcript.WriteString("Assistant: " + msg.Content + "\n")
+			}
+		case openai.ChatMessageRoleTool:
+			transcript.WriteString(fmt.Sprintf("Output of %s: %s\n", msg.Name, truncate(msg.Content, transcriptToolOutput)))
+		}
+	}
+
+	resp, err := c.openai.CreateChatCompletion(c.ctx, openai.ChatCompletionRequest{
+		Model: c.req.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: summaryPrompt + transcript.String()},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.usedTokens += resp.Usage.TotalTokens
+
+	compacted := []openai.ChatCompletionMessage{messages[0]}
+	for _, msg := range summaryMessages(resp.Choices[0].Message.Content) {
+		role := openai.ChatMessageRoleUser
+		if msg.Role == "Assistant" {
+			role = openai.ChatMessageRoleAssistant
+		}
+		compacted = append(compacted, openai.ChatCompletionMessage{Role: role, Content: msg.Content})
+	}
+	c.req.Messages = append(compacted, messages[split:]...)
+	return nil
+}
+
+func (c *chat) compactGemini(force bool) error {
+	history := c.history
+	split := recentStart(len(history), func(i int) bool {
+		return history[i].Role == "User"
+	})
+	if split == 0 {
+		return nil
+	}
+
+	// The tool messages stay so every function call keeps its response
+	for _, msg := range history[:split] {
+		if msg.Role == "Tool" && len(msg.Content) > staleToolOutput {
+			msg.Content = droppedOutput
+		}
+	}
+	if !force && c.estimateTokens() <= compactThreshold {
+		return nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range history[:split] {
+		switch msg.Role {
+		case "Tool":
+			transcript.WriteString(fmt.Sprintf("Output of %s: %s\n", msg.Name, truncate(msg.Content, transcriptToolOutput)))
+		case "Assistant":
+			for _, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Args)
+				transcript.WriteString(fmt.Sprintf("Assistant called %s %s\n", tc.Name, args))
+			}
+			if msg.Content != "" {
+				transcript.WriteString("Assistant: " + msg.Content + "\n")
+			}
+		default:
+			transcript.WriteString(msg.Role + ": " + msg.Content + "\n")
+		}
+	}
+
+	model := c.gemini.GenerativeModel(c.client.Engine.Version)
+	resp, err := model.GenerateContent(c.ctx, genai.Text(summaryPrompt+transcript.String()))
+	if err != nil {
+		return err
+	}
+	if resp.UsageMetadata != nil {
+		c.usedTokens += int(resp.UsageMetadata.TotalTokenCount)
+	}
+	var summary strings.Builder
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			if text, ok := part.(genai.Text); ok {
+				summary.WriteString(string(text))
+			}
+		}
+	}
+	if summary.Len() == 0 {
+		return fmt.Errorf("the model returned an empty summary")
+	}
+
+	c.history = append(summaryMessages(summary.String()), history[split:]...)
+	return nil
+}
+
+// summaryMessages replaces the older turns. The summary is a user message,
+// not a system one, so it is stored with the session and works with both providers.
+// Config blocks not applied yet are kept so /apply and ReviewConfig still match the conversation.
+func summaryMessages(summary string) []*ChatMessage {
+	content := "Summary of the earlier conversation:\n" + strings.TrimSpace(summary)
+	if len(cisco.CodeBlocks) > 0 {
+		content += "\n\nConfiguration proposed to the user and not applied yet:\n```\n" + strings.Join(cisco.CodeBlocks, "") + "```"
+	}
+	return []*ChatMessage{
+		{Role: "User", Content: content},
+		{Role: "Assistant", Content: "Noted."},
+	}
+}
+
+func truncate(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	return text[:size] + "..."
+}
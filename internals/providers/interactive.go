@@ -2,14 +2,15 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
 	"github.com/chzyer/readline"
 	"github.com/sashabaranov/go-openai"
 	"github.com/google/generative-ai-go/genai"
@@ -17,266 +18,418 @@ import (
 )
 
 type ChatMessage struct {
-	Role	string `json:"role"` // "User" or "Assistant"
+	Role    string `json:"role"` // "User", "Assistant" or "Tool"
 	Content string `json:"content"`
+	// Tools called by the assistant, and the tool a "Tool" message is the output of
+	ToolCalls []SessionToolCall `json:"tool_calls,omitempty"`
+	Name      string            `json:"name,omitempty"`
 }
 
-func (a *Client) Interactive(sn string) {
+const systemPrompt = "You are a Cisco IOS-XE configuration assistant and you answer only to IOS-XE related questions. Put all commands that you suggest in code blocks"
 
+// chat holds the conversation with the configured provider
+type chat struct {
+	client  *Client
+	session *Session
+	ctx     context.Context
+
+	// OpenAI conversation
+	openai *openai.Client
+	req    openai.ChatCompletionRequest
+
+	// Gemini conversation
+	gemini  *genai.Client
+	model   *genai.GenerativeModel
+	history []*ChatMessage
+
+	// Tokens reported by the provider since the chat started
+	usedTokens int
+	// Batch chats have no terminal, changes run only with /apply and allowApply
+	batch      bool
+	allowApply bool
+}
+
+func (a *Client) newChat(session *Session) (*chat, error) {
+	c := &chat{client: a, session: session, ctx: context.Background()}
 	switch a.Engine.Provider {
 	case "openai":
-		client := openai.NewClient(a.API)
-
-		req := openai.ChatCompletionRequest{
+		c.openai = openai.NewClient(a.API)
+		c.req = openai.ChatCompletionRequest{
 			Model: a.Engine.Version,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are a Cisco IOS-XE configuration assistant and you answer only to IOS-XE related questions. Put all commands that you suggest in code blocks",
+					Content: a.systemPrompt(),
 				},
 			},
-			Tools: []openai.Tool{cisco.Show_cdp_tool_openai, cisco.Show_ip_route_tool_openai, cisco.Show_ip_int_br_tool_openai, cisco.Show_vlan_tool_openai, cisco.Show_stp_tool_openai, cisco.Show_mac_address_tool_openai, cisco.Show_arp_tool_openai, cisco.Review_config_tool_openai},
-		}
-		ctx := context.Background()
-
-		cisco.Rl, _ = readline.NewEx(&readline.Config{
-			Prompt:          "> ",
-			InterruptPrompt: "^C",
-			EOFPrompt:       "exit",
-			HistoryFile:     "/tmp/readline.tmp",
-		})
-		defer cisco.Rl.Close()
-
-		printInstructions()
-
-		for {
-			line, err := cisco.Rl.Readline()
-			if err == readline.ErrInterrupt {
-				if len(line) == 0 {
-					break
-				}
-				continue
-			} else if err == io.EOF {
-				break
-			}
-
-			line = strings.TrimSpace(line)
-			switch strings.ToLower(line) {
-			case "exit":
-				// printChatHistory(req.Messages[1:])
-				writeChatHistoryToFile(req.Messages[1:])
-				return
-			default:
-				handleOpenAIChatCompletion(client, &req, line, &ctx)
-			}
+			Tools: cisco.OpenAITools(),
 		}
+		c.req.Messages = append(c.req.Messages, session.openAIMessages()...)
 	case "gemini":
-		ctx := context.Background()
-		client, err := genai.NewClient(ctx, option.WithAPIKey(a.API))
+		client, err := genai.NewClient(c.ctx, option.WithAPIKey(a.API))
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
-		defer client.Close()
+		c.gemini = client
+		c.model = c.geminiModel(a.Engine.Version)
+		c.history = session.geminiHistory()
+	default:
+		return nil, fmt.Errorf("provider %s is not supported", a.Engine.Provider)
+	}
+	return c, nil
+}
+
+func (c *chat) geminiModel(version string) *genai.GenerativeModel {
+	model := c.gemini.GenerativeModel(version)
+	model.Tools = []*genai.Tool{cisco.GeminiTools()}
+	model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(c.client.systemPrompt())}}
+	model.SetMaxOutputTokens(1024)
+	return model
+}
+
+// The device facts are part of the system prompt so they survive the compaction of the conversation
+func (a *Client) systemPrompt() string {
+	prompt := systemPrompt + "\n" + untrusted.Instructions
+	if a.Facts == "" {
+		return prompt
+	}
+	return prompt + "\n" + a.Facts
+}
+
+func (c *chat) close() {
+	if c.gemini != nil {
+		c.gemini.Close()
+	}
+}
 
-		model := client.GenerativeModel(a.Engine.Version)
-		model.Tools = []*genai.Tool{&cisco.Tools_gemini}
-		model.SetMaxOutputTokens(1024)
+// ask sends the question to the model and prints the answer
+func (c *chat) ask(line string) {
+	if c.estimateTokens() > compactThreshold {
+		c.compact(false)
+	}
+	// The change history keeps the question as typed
+	cisco.Change.Question = line
+	cisco.Change.Model = c.client.Engine.Version
+	cisco.Change.SessionID = c.session.ID
+	line = redact.Text(line)
+	if c.openai != nil {
+		c.handleOpenAIChatCompletion(line)
+	} else {
+		c.handleGeminiChatCompletion(line)
+	}
+	c.sync()
+}
 
-		// Initialize chat history for Gemini
-        var chatHistory []ChatMessage
+// sync copies the conversation to the session
+func (c *chat) sync() {
+	if c.openai != nil {
+		c.session.setOpenAI(c.req.Messages)
+	} else {
+		c.session.setGemini(c.history)
+	}
+}
 
-		cisco.Rl, _ = readline.NewEx(&readline.Config{
-			Prompt:          "> ",
-			InterruptPrompt: "^C",
-			EOFPrompt:       "exit",
-			HistoryFile:     "/tmp/readline.tmp",
+// inject adds information to the conversation without asking the model
+func (c *chat) inject(text string) {
+	text = redact.Text(text)
+	if c.openai != nil {
+		c.req.Messages = append(c.req.Messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: text,
 		})
-		defer cisco.Rl.Close()
+	} else {
+		// Gemini expects the user and the model to alternate
+		c.history = append(c.history,
+			&ChatMessage{Role: "User", Content: text},
+			&ChatMessage{Role: "Assistant", Content: "Noted."})
+	}
+	c.sync()
+}
 
-		printInstructions()
+// reset starts a new session, the current one stays stored
+func (c *chat) reset() {
+	saveSession(c.session, false)
+	c.session = NewSession(c.client.Engine, c.session.SerialNumber)
+	cisco.OnApply = c.session.addAppliedConfig
+	cisco.CodeBlocks = nil
+	if c.openai != nil {
+		// Only the system prompt is kept
+		c.req.Messages = c.req.Messages[:1]
+	} else {
+		c.history = nil
+	}
+	c.usedTokens = 0
+}
 
-		for {
-			line, err := cisco.Rl.Readline()
-			if err == readline.ErrInterrupt {
-				if len(line) == 0 {
-					break
-				}
-				continue
-			} else if err == io.EOF {
+func (c *chat) setModel(version string) {
+	c.client.Engine.Version = version
+	c.session.Model = version
+	if c.openai != nil {
+		c.req.Model = version
+	} else {
+		c.model = c.geminiModel(version)
+	}
+}
+
+// Interactive starts the chat. When session is not nil the conversation is resumed from it.
+func (a *Client) Interactive(sn string, session *Session) {
+	if session == nil {
+		session = NewSession(a.Engine, sn)
+	}
+	c, err := a.newChat(session)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.close()
+	cisco.OnApply = session.addAppliedConfig
+	cisco.Verify = c.verify
+
+	cisco.Rl, _ = readline.NewEx(&readline.Config{
+		Prompt:          "> ",
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+		HistoryFile:     "/tmp/readline.tmp",
+		AutoComplete:    slashCompleter(),
+	})
+	defer cisco.Rl.Close()
+
+	printInstructions(session)
+
+	for {
+		line, err := cisco.Rl.Readline()
+		if err == readline.ErrInterrupt {
+			if len(line) == 0 {
 				break
 			}
+			continue
+		} else if err == io.EOF {
+			break
+		}
 
-			line = strings.TrimSpace(line)
-			switch strings.ToLower(line) {
-			case "exit":
-				// printChatHistory(req.Messages[1:])
-				writeChatHistoryToFile(chatHistory[1:])
-				return
-			default:
-				handleGeminiChatCompletion(model, &chatHistory, line, &ctx)
-			}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.ToLower(line) == "exit":
+			saveSession(c.session, true)
+			return
+		case strings.HasPrefix(line, "/"):
+			c.slashCommand(line)
+		default:
+			c.ask(line)
+			saveSession(c.session, false)
+		}
+	}
+	saveSession(c.session, true)
+}
+
+// Batch asks the questions one after the other through the same agent loop
+// as Interactive, without a terminal. Lines starting with '/' are chat commands.
+// When session is not nil the conversation is resumed from it.
+// It returns the session holding the conversation.
+func (a *Client) Batch(sn string, session *Session, questions []string, allowApply bool) (*Session, error) {
+	if session == nil {
+		session = NewSession(a.Engine, sn)
+	}
+	c, err := a.newChat(session)
+	if err != nil {
+		return session, err
+	}
+	defer c.close()
+	c.batch = true
+	c.allowApply = allowApply
+	cisco.OnApply = session.addAppliedConfig
+	cisco.Verify = c.verify
+	cisco.AutoApprove = allowApply
+
+	for _, question := range questions {
+		fmt.Println(cisco.Green + "> " + question + cisco.Reset)
+		if strings.HasPrefix(question, "/") {
+			c.slashCommand(question)
+		} else {
+			c.ask(question)
 		}
+		saveSession(c.session, false)
+	}
+	return c.session, nil
+}
+
+// saveSession stores the session after every answer so nothing is lost on a crash
+func saveSession(session *Session, exiting bool) {
+	if len(session.Messages) == 0 {
+		return
+	}
+	if err := session.Save(); err != nil {
+		fmt.Println("Error saving the chat session:", err)
+		return
+	}
+	if exiting {
+		fmt.Printf("Session %s saved. Resume it with aixedge-chat --resume %s\n", session.ID, session.ID)
 	}
 }
 
-func printInstructions() {
+func printInstructions(session *Session) {
 	fmt.Println("IOS-XE AI Assistant")
-	fmt.Println("Type 'exit' to end the conversation.")
+	if len(session.Messages) > 0 {
+		fmt.Printf("Resuming session %s: %s\n", session.ID, session.Title())
+	}
+	fmt.Println("Type 'exit' to end the conversation or /help for the chat commands.")
 }
 
-func handleOpenAIChatCompletion(client *openai.Client, req *openai.ChatCompletionRequest, line string, ctx *context.Context) {
-	req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+func (c *chat) handleOpenAIChatCompletion(line string) {
+	c.req.Messages = append(c.req.Messages, openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleUser,
 		Content: line,
 	})
-	resp, err := client.CreateChatCompletion(*ctx, *req)
-	if err != nil {
-		fmt.Printf("ChatCompletion error: %v\n", err)
-		return
-	}
-	msg := resp.Choices[0].Message
-	if len(msg.ToolCalls) == 0 {
-		cisco.CodeBlocks = printFormattedContent(resp.Choices[0].Message.Content)
-		req.Messages = append(req.Messages, resp.Choices[0].Message)
-	} else {
-		funcName := msg.ToolCalls[0].Function.Name
-		answer, err := cisco.CallFunctionByName(funcName)
+	// Agent loop: the model may call tools several times before giving its final answer
+	for step := 0; step < maxAgentSteps; step++ {
+		resp, err := c.openai.CreateChatCompletion(c.ctx, c.req)
 		if err != nil {
-			fmt.Printf("Function calling error: %v\n", err)
+			fmt.Printf("ChatCompletion error: %v\n", err)
 			return
 		}
-		if funcName != "ReviewConfig" {
-			resp, err = client.CreateChatCompletion(*ctx,
-				openai.ChatCompletionRequest{
-					Model: req.Model,
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleSystem,
-							Content: answer.(string),
-						},
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: line,
-						},
-					},
-				},
-			)
-			if err != nil {
-				fmt.Printf("ChatCompletion error: %v\n", err)
-				return
+		c.usedTokens += resp.Usage.TotalTokens
+		msg := resp.Choices[0].Message
+		// The tool calls of the last step are not run, like in the Gemini loop. They
+		// are not kept either, every call in the messages needs its response.
+		spent := len(msg.ToolCalls) > 0 && step+1 >= maxAgentSteps
+		if spent {
+			msg.ToolCalls = nil
+		}
+		if msg.Content != "" || len(msg.ToolCalls) > 0 {
+			c.req.Messages = append(c.req.Messages, msg)
+		}
+		if len(msg.ToolCalls) == 0 {
+			if blocks := printFormattedContent(msg.Content); len(blocks) > 0 || !spent {
+				cisco.CodeBlocks = blocks
 			}
-			cisco.CodeBlocks = printFormattedContent(resp.Choices[0].Message.Content)
-			req.Messages = append(req.Messages, resp.Choices[0].Message)
-		} else {
-			resp, err = client.CreateChatCompletion(*ctx,
-				openai.ChatCompletionRequest{
-					Model: "gpt-4o",
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: "Thank you!",
-						},
-					},
-				},
-			)
-			if err != nil {
-				fmt.Printf("ChatCompletion error: %v\n", err)
-				return
+			if spent {
+				fmt.Println("The assistant reached the maximum number of steps for this question.")
+			}
+			return
+		}
+		if msg.Content != "" {
+			if blocks := printFormattedContent(msg.Content); len(blocks) > 0 {
+				cisco.CodeBlocks = blocks
+			}
+		}
+
+		var calls []toolCall
+		for _, tc := range msg.ToolCalls {
+			args := map[string]any{}
+			if tc.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+					fmt.Printf("Function calling error: %v\n", err)
+				}
+			}
+			calls = append(calls, toolCall{ID: tc.ID, Name: tc.Function.Name, Args: args})
+		}
+		for _, result := range c.runToolCalls(calls) {
+			c.req.Messages = append(c.req.Messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    result.Output,
+				Name:       result.Call.Name,
+				ToolCallID: result.Call.ID,
+			})
+		}
+	}
+}
+
+// geminiContents rebuilds the Gemini history: tool calls are sent back as function
+// calls of the model and the tool outputs as function responses of the user.
+// Consecutive turns of the same role are merged, Gemini expects them to alternate.
+func geminiContents(history []*ChatMessage) []*genai.Content {
+	var contents []*genai.Content
+	for _, msg := range history {
+		role := "user"
+		var parts []genai.Part
+		switch msg.Role {
+		case "User":
+			parts = append(parts, genai.Text(msg.Content))
+		case "Assistant":
+			role = "model"
+			if msg.Content != "" {
+				parts = append(parts, genai.Text(msg.Content))
+			}
+			for _, tc := range msg.ToolCalls {
+				parts = append(parts, genai.FunctionCall{Name: tc.Name, Args: tc.Args})
 			}
-			cisco.CodeBlocks = printFormattedContent(resp.Choices[0].Message.Content)
-			req.Messages = append(req.Messages, resp.Choices[0].Message)
+		case "Tool":
+			parts = append(parts, genai.FunctionResponse{Name: msg.Name, Response: map[string]any{"result": msg.Content}})
 		}
+		if len(parts) == 0 {
+			continue
+		}
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == role {
+			contents[last].Parts = append(contents[last].Parts, parts...)
+			continue
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: parts})
 	}
+	return contents
 }
 
-func handleGeminiChatCompletion(model *genai.GenerativeModel, chatHistory *[]ChatMessage, line string, ctx *context.Context) {
-    // Add user message to chat history first
-    *chatHistory = append(*chatHistory, ChatMessage{Role: "User", Content: line})
-    
-    // Start a chat session to maintain conversation history
-    cs := model.StartChat()
-    
-    // Add previous messages to the chat session (excluding the current user message we just added)
-    for i, msg := range *chatHistory {
-        if i == len(*chatHistory)-1 {
-            break // Skip the current message we just added
-        }
-        if msg.Role == "User" {
-            cs.History = append(cs.History, &genai.Content{
-                Parts: []genai.Part{genai.Text(msg.Content)},
-                Role:  "user",
-            })
-        } else if msg.Role == "Assistant" {
-            cs.History = append(cs.History, &genai.Content{
-                Parts: []genai.Part{genai.Text(msg.Content)},
-                Role:  "model",
-            })
-        }
-    }
-    
-    resp, err := cs.SendMessage(*ctx, genai.Text(line))
-    if err != nil {
-        log.Fatal(err)
-    }
-
-    // Extract and handle the response content
-    if resp.Candidates != nil && len(resp.Candidates) > 0 {
-        candidate := resp.Candidates[0]
-        if candidate.Content != nil && candidate.Content.Parts != nil {
-            
-            var hasToolCall bool
-            var toolCallResults []genai.Part
-            
-            // First pass: check for tool calls and execute them
-            for _, part := range candidate.Content.Parts {
-                if fnCall, ok := part.(genai.FunctionCall); ok {
-                    hasToolCall = true
-                    
-                    // Execute the function call
-                    answer, err := cisco.CallFunctionByName(fnCall.Name)
-                    if err != nil {
-                        fmt.Printf("Function calling error: %v\n", err)
-                        return
-                    }
-                    
-                    // Create function response part
-                    toolCallResults = append(toolCallResults, genai.FunctionResponse{
-                        Name:     fnCall.Name,
-                        Response: map[string]any{"result": answer},
-                    })
-                }
-            }
-            
-            if hasToolCall {
-                // Send function responses back through the chat session
-                finalResp, err := cs.SendMessage(*ctx, toolCallResults...)
-                if err != nil {
-                    log.Fatal(err)
-                }
-                
-                // Process the final response
-                if finalResp.Candidates != nil && len(finalResp.Candidates) > 0 {
-                    finalCandidate := finalResp.Candidates[0]
-                    if finalCandidate.Content != nil && finalCandidate.Content.Parts != nil {
-                        for _, part := range finalCandidate.Content.Parts {
-                            if textPart, ok := part.(genai.Text); ok {
-                                *chatHistory = append(*chatHistory, ChatMessage{Role: "Assistant", Content: string(textPart)})
-                                cisco.CodeBlocks = printFormattedContent(string(textPart))
-                            }
-                        }
-                    }
-                }
-            } else {
-                // No tool calls, process text normally
-                for _, part := range candidate.Content.Parts {
-                    if textPart, ok := part.(genai.Text); ok {
-                        *chatHistory = append(*chatHistory, ChatMessage{Role: "Assistant", Content: string(textPart)})
-                        cisco.CodeBlocks = printFormattedContent(string(textPart))
-                    }
-                }
-            }
-        }
-    }
+func (c *chat) handleGeminiChatCompletion(line string) {
+	// Start a chat session to maintain conversation history
+	cs := c.model.StartChat()
+	cs.History = geminiContents(c.history)
+	c.history = append(c.history, &ChatMessage{Role: "User", Content: line})
+
+	resp, err := cs.SendMessage(c.ctx, genai.Text(line))
+	// Agent loop: function responses are sent back until the model answers with text only
+	for step := 0; ; step++ {
+		if err != nil {
+			fmt.Printf("Gemini error: %v\n", err)
+			return
+		}
+		if resp.UsageMetadata != nil {
+			c.usedTokens += int(resp.UsageMetadata.TotalTokenCount)
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			return
+		}
+
+		var calls []toolCall
+		var texts []string
+		for _, part := range resp.Candidates[0].Content.Parts {
+			switch p := part.(type) {
+			case genai.FunctionCall:
+				calls = append(calls, toolCall{Name: p.Name, Args: p.Args})
+			case genai.Text:
+				texts = append(texts, string(p))
+			}
+		}
+		for _, text := range texts {
+			if blocks := printFormattedContent(text); len(blocks) > 0 || len(calls) == 0 {
+				cisco.CodeBlocks = blocks
+			}
+		}
+		answer := &ChatMessage{Role: "Assistant", Content: strings.Join(texts, "")}
+		if len(calls) == 0 || step >= maxAgentSteps {
+			// The calls not run are not kept, every call in the history has its response
+			if answer.Content != "" {
+				c.history = append(c.history, answer)
+			}
+			if len(calls) > 0 {
+				fmt.Println("The assistant reached the maximum number of steps for this question.")
+			}
+			return
+		}
+		for _, call := range calls {
+			answer.ToolCalls = append(answer.ToolCalls, SessionToolCall{Name: call.Name, Args: call.Args})
+		}
+		c.history = append(c.history, answer)
+
+		var responses []genai.Part
+		for _, result := range c.runToolCalls(calls) {
+			responses = append(responses, genai.FunctionResponse{
+				Name:     result.Call.Name,
+				Response: map[string]any{"result": result.Output},
+			})
+			c.history = append(c.history, &ChatMessage{Role: "Tool", Content: result.Output, Name: result.Call.Name})
+		}
+		resp, err = cs.SendMessage(c.ctx, responses...)
+	}
 }
 
 func printFormattedContent(content string) []string {
@@ -339,41 +492,3 @@ func printHighlightedLine(line string) {
 	result.WriteString(cisco.Reset)
 	fmt.Println(result.String())
 }
-
-func writeChatHistoryToFile(messages interface{}) {
-	// Open the file in append mode, or create it if it doesn't exist
-	file, _ := os.OpenFile("chat.telemetry", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	defer file.Close()
-	cmd := exec.Command("python3", "cmd.py", "-c", "show clock")
-	out, _ := cmd.Output()
-
-	timestamp := string(out)
-	file.WriteString(fmt.Sprintf("\nTime: %s\n\n", timestamp))
-	
-	// Write chat history to the file based on type
-	switch msgs := messages.(type) {
-	case []openai.ChatCompletionMessage:
-		for _, msg := range msgs {
-			switch msg.Role {
-			case openai.ChatMessageRoleUser:
-				_, _ = file.WriteString(fmt.Sprintf("User: %s\n", msg.Content))
-			case openai.ChatMessageRoleAssistant:
-				_, _ = file.WriteString(fmt.Sprintf("Assistant: %s\n", msg.Content))
-			}
-		}
-	case []*ChatMessage:
-		for _, msg := range msgs {
-			switch msg.Role {
-			case "User":
-				_, _ = file.WriteString(fmt.Sprintf("User: %s\n", msg.Content))
-			case "Assistant":
-				_, _ = file.WriteString(fmt.Sprintf("Assistant: %s\n", msg.Content))
-			}
-		}
-	default:
-		_, _ = file.WriteString("Error: Unsupported message type\n")
-	}
-
-	// Delete local file
-	_ = os.Remove("chat.telemetry")
-}
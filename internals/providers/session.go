@@ -0,0 +1,259 @@
+package providers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Chat sessions are stored as JSON, one file per session
+const SessionDir = ".sessions"
+
+type SessionToolCall struct {
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type SessionMessage struct {
+	Role       string            `json:"role"` // "user", "assistant" or "tool"
+	Content    string            `json:"content"`
+	ToolCalls  []SessionToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+	Name       string            `json:"name,omitempty"`
+}
+
+type AppliedConfig struct {
+	Timestamp time.Time `json:"timestamp"`
+	Config    string    `json:"config"`
+}
+
+type Session struct {
+	ID             string           `json:"id"`
+	Provider       string           `json:"provider"`
+	Model          string           `json:"model"`
+	SerialNumber   string           `json:"serialnumber,omitempty"`
+	Created        time.Time        `json:"created"`
+	Updated        time.Time        `json:"updated"`
+	Messages       []SessionMessage `json:"messages"`
+	AppliedConfigs []AppliedConfig  `json:"applied_configs,omitempty"`
+}
+
+func NewSession(engine Engine, sn string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:           newSessionID(now),
+		Provider:     engine.Provider,
+		Model:        engine.Version,
+		SerialNumber: sn,
+		Created:      now,
+		Updated:      now,
+	}
+}
+
+// The random suffix keeps sessions started in the same second apart
+func newSessionID(now time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+func sessionPath(id string) string {
+	return filepath.Join(SessionDir, filepath.Base(id)+".json")
+}
+
+// LoadSession reads a stored session by its ID
+func LoadSession(id string) (*Session, error) {
+	data, err := os.ReadFile(sessionPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("session %s is corrupted: %v", id, err)
+	}
+	return session, nil
+}
+
+// ListSessions returns the stored sessions, most recent first
+func ListSessions() ([]*Session, error) {
+	files, err := filepath.Glob(filepath.Join(SessionDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, file := range files {
+		session, err := LoadSession(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// Save stores the session. It holds device output, so only the user can read it.
+func (s *Session) Save() error {
+	s.Updated = time.Now()
+	if err := os.MkdirAll(SessionDir, 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(sessionPath(s.ID), b, 0600); err != nil {
+		return err
+	}
+	// Sessions saved by older versions were readable by everyone
+	return os.Chmod(sessionPath(s.ID), 0600)
+}
+
+// Title is the first question of the session
+func (s *Session) Title() string {
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			title := strings.ReplaceAll(msg.Content, "\n", " ")
+			if len(title) > 60 {
+				title = title[:57] + "..."
+			}
+			return title
+		}
+	}
+	return "(empty)"
+}
+
+func (s *Session) addAppliedConfig(config string) {
+	s.AppliedConfigs = append(s.AppliedConfigs, AppliedConfig{Timestamp: time.Now(), Config: config})
+}
+
+// setOpenAI stores the OpenAI conversation, without the system prompt
+func (s *Session) setOpenAI(messages []openai.ChatCompletionMessage) {
+	s.Messages = nil
+	for _, msg := range messages {
+		if msg.Role == openai.ChatMessageRoleSystem {
+			continue
+		}
+		m := SessionMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.Name,
+		}
+		for _, tc := range msg.ToolCalls {
+			args := map[string]any{}
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			m.ToolCalls = append(m.ToolCalls, SessionToolCall{ID: tc.ID, Name: tc.Function.Name, Args: args})
+		}
+		s.Messages = append(s.Messages, m)
+	}
+}
+
+// openAIMessages rebuilds the OpenAI conversation of a resumed session.
+// Tool calls without ID (e.g. from a Gemini session) are kept as text only.
+func (s *Session) openAIMessages() []openai.ChatCompletionMessage {
+	var messages []openai.ChatCompletionMessage
+	for _, m := range s.Messages {
+		msg := openai.ChatCompletionMessage{Role: m.Role, Content: m.Content, Name: m.Name, ToolCallID: m.ToolCallID}
+		if m.Role == openai.ChatMessageRoleTool && m.ToolCallID == "" {
+			continue
+		}
+		for _, tc := range m.ToolCalls {
+			if tc.ID == "" {
+				continue
+			}
+			args, _ := json.Marshal(tc.Args)
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       tc.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: tc.Name, Arguments: string(args)},
+			})
+		}
+		if msg.Content == "" && len(msg.ToolCalls) == 0 {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// setGemini stores the Gemini conversation with its tool calls and outputs
+func (s *Session) setGemini(history []*ChatMessage) {
+	s.Messages = nil
+	for _, msg := range history {
+		m := SessionMessage{Role: "user", Content: msg.Content, ToolCalls: msg.ToolCalls, Name: msg.Name}
+		switch msg.Role {
+		case "Assistant":
+			m.Role = "assistant"
+		case "Tool":
+			m.Role = "tool"
+		}
+		s.Messages = append(s.Messages, m)
+	}
+}
+
+// geminiHistory rebuilds the Gemini conversation of a resumed session,
+// from a Gemini or an OpenAI session.
+func (s *Session) geminiHistory() []*ChatMessage {
+	var history []*ChatMessage
+	for _, m := range s.Messages {
+		switch {
+		case m.Role == "user":
+			history = append(history, &ChatMessage{Role: "User", Content: m.Content})
+		case m.Role == "assistant" && (m.Content != "" || len(m.ToolCalls) > 0):
+			history = append(history, &ChatMessage{Role: "Assistant", Content: m.Content, ToolCalls: m.ToolCalls})
+		case m.Role == "tool" && m.Name != "":
+			history = append(history, &ChatMessage{Role: "Tool", Content: m.Content, Name: m.Name})
+		}
+	}
+	return history
+}
+
+// Markdown exports the session, e.g. to attach it to a change ticket
+func (s *Session) Markdown() string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# AIXEdge chat session %s\n\n", s.ID))
+	md.WriteString(fmt.Sprintf("- Model: %s (%s)\n", s.Model, s.Provider))
+	if s.SerialNumber != "" {
+		md.WriteString(fmt.Sprintf("- Device serial number: %s\n", s.SerialNumber))
+	}
+	md.WriteString(fmt.Sprintf("- Started: %s\n", s.Created.Format(time.RFC1123)))
+	md.WriteString(fmt.Sprintf("- Last update: %s\n\n", s.Updated.Format(time.RFC1123)))
+
+	md.WriteString("## Conversation\n\n")
+	for _, m := range s.Messages {
+		switch m.Role {
+		case "user":
+			md.WriteString("**User:** " + m.Content + "\n\n")
+		case "assistant":
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Args)
+				md.WriteString(fmt.Sprintf("> Tool call `%s` %s\n\n", tc.Name, string(args)))
+			}
+			if m.Content != "" {
+				md.WriteString("**Assistant:** " + m.Content + "\n\n")
+			}
+		case "tool":
+			md.WriteString(fmt.Sprintf("<details><summary>Output of %s</summary>\n\n```\n%s\n```\n</details>\n\n", m.Name, strings.TrimSpace(m.Content)))
+		}
+	}
+
+	if len(s.AppliedConfigs) > 0 {
+		md.WriteString("## Applied configuration\n\n")
+		for _, applied := range s.AppliedConfigs {
+			md.WriteString(fmt.Sprintf("%s\n\n```\n%s\n```\n\n", applied.Timestamp.Format(time.RFC1123), applied.Config))
+		}
+	}
+	return md.String()
+}
@@ -0,0 +1,166 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
+	"github.com/chzyer/readline"
+)
+
+// Commands typed in the chat starting with '/' are handled locally and
+// are not sent to the model.
+
+type slashCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(c *chat, arg string)
+}
+
+var slashCommands []slashCommand
+
+func init() {
+	// Declared in init as /help refers to the list itself
+	slashCommands = []slashCommand{
+		{"/run", "/run <show command>", "Run a show command and add its output to the conversation", slashRun},
+		{"/apply", "/apply", "Review and apply the configuration of the last answer", slashApply},
+		{"/model", "/model [name]", "Show or change the model of the conversation", slashModel},
+		{"/clear", "/clear", "Start a new conversation, the current one stays stored", slashClear},
+		{"/save", "/save [file.md]", "Save the session, optionally exported as markdown", slashSave},
+		{"/history", "/history", "Print the conversation so far", slashHistory},
+		{"/tokens", "/tokens", "Print the token usage of the conversation", slashTokens},
+		{"/compact", "/compact", "Summarise the older part of the conversation", slashCompact},
+		{"/help", "/help", "Print the chat commands", slashHelp},
+	}
+}
+
+// slashCompleter completes the command names with the tab key
+func slashCompleter() *readline.PrefixCompleter {
+	var items []readline.PrefixCompleterInterface
+	for _, cmd := range slashCommands {
+		if cmd.Name == "/run" {
+			items = append(items, readline.PcItem(cmd.Name, readline.PcItem("show")))
+			continue
+		}
+		items = append(items, readline.PcItem(cmd.Name))
+	}
+	return readline.NewPrefixCompleter(items...)
+}
+
+func (c *chat) slashCommand(line string) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	for _, cmd := range slashCommands {
+		if strings.EqualFold(cmd.Name, name) {
+			cmd.Run(c, arg)
+			return
+		}
+	}
+	fmt.Printf("Unknown command %s. Type /help for the chat commands.\n", name)
+}
+
+func slashRun(c *chat, arg string) {
+	command := strings.Join(strings.Fields(arg), " ")
+	if command == "" {
+		fmt.Println("Usage: /run <show command>")
+		return
+	}
+	if !cisco.IsValidShowCommand(command) {
+		fmt.Printf("The command '%s' is not allowed.\n", command)
+		return
+	}
+	output := cisco.Run_show_command(command)
+	fmt.Println(output)
+	c.inject(untrusted.Wrap(command, output))
+	saveSession(c.session, false)
+}
+
+func slashApply(c *chat, arg string) {
+	if c.batch && !c.allowApply {
+		fmt.Println("Applying configuration is disabled, run the script with --allow-apply")
+		return
+	}
+	outcome := cisco.ReviewConfig()
+	fmt.Println(outcome)
+	if len(cisco.CodeBlocks) > 0 {
+		c.inject(outcome)
+		saveSession(c.session, false)
+	}
+	// The outcome holds the rejected lines, the model answers with the fix
+	if cisco.FixRequested {
+		c.ask("Fix the configuration lines IOS rejected.")
+		saveSession(c.session, false)
+	}
+}
+
+func slashModel(c *chat, arg string) {
+	if arg == "" {
+		fmt.Printf("Model: %s (%s)\n", c.client.Engine.Version, c.client.Engine.Provider)
+		return
+	}
+	c.setModel(arg)
+	fmt.Printf("Model changed to %s\n", arg)
+}
+
+func slashClear(c *chat, arg string) {
+	c.reset()
+	fmt.Printf("New session %s started\n", c.session.ID)
+}
+
+func slashSave(c *chat, arg string) {
+	c.sync()
+	if err := c.session.Save(); err != nil {
+		fmt.Println("Error saving the chat session:", err)
+		return
+	}
+	fmt.Printf("Session %s saved\n", c.session.ID)
+	if arg == "" {
+		return
+	}
+	if err := os.WriteFile(arg, []byte(c.session.Markdown()), 0600); err != nil {
+		fmt.Println("Error writing file:", err)
+		return
+	}
+	fmt.Printf("Session exported to %s\n", arg)
+}
+
+func slashHistory(c *chat, arg string) {
+	c.sync()
+	if len(c.session.Messages) == 0 {
+		fmt.Println("The conversation is empty")
+		return
+	}
+	for _, m := range c.session.Messages {
+		switch m.Role {
+		case "user":
+			fmt.Println(cisco.Green + "> " + m.Content + cisco.Reset)
+		case "assistant":
+			for _, tc := range m.ToolCalls {
+				fmt.Printf("[tool %s]\n", tc.Name)
+			}
+			if m.Content != "" {
+				printFormattedContent(m.Content)
+			}
+		}
+	}
+}
+
+func slashTokens(c *chat, arg string) {
+	fmt.Printf("Conversation size: about %d tokens\n", c.estimateTokens())
+	fmt.Printf("Tokens used since the chat started: %d\n", c.usedTokens)
+}
+
+func slashCompact(c *chat, arg string) {
+	c.compact(true)
+	saveSession(c.session, false)
+}
+
+func slashHelp(c *chat, arg string) {
+	for _, cmd := range slashCommands {
+		fmt.Printf("  %-24s %s\n", cmd.Usage, cmd.Description)
+	}
+	fmt.Println("  exit                     End the conversation")
+}
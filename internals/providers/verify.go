@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/redact"
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/untrusted"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+)
+
+const verifyPrompt = "You verify changes applied to a Cisco IOS-XE device by an assistant. " +
+	"Given the request of the network engineer, the configuration applied and the outputs collected from the device afterwards, " +
+	"tell whether the request was achieved. Answer with a JSON object: " +
+	`{"verdict": "success" | "partial" | "failed", "summary": "one or two sentences", "follow_up": "what to do next, empty on success"}.` +
+	" An interface down because nothing is connected does not make the change fail."
+
+// verify is the cisco.Verify hook: the model judges the outputs collected after a change
+func (c *chat) verify(question string, config string, outputs string) (cisco.Verification, error) {
+	prompt := fmt.Sprintf("Request: %s\n\nConfiguration applied:\n```\n%s\n```\n\n%s",
+		redact.Text(question), redact.Text(config), untrusted.Wrap("verification", redact.Text(outputs)))
+	system := verifyPrompt + "\n" + untrusted.Instructions
+
+	var answer string
+	if c.openai != nil {
+		resp, err := c.openai.CreateChatCompletion(c.ctx, openai.ChatCompletionRequest{
+			Model: c.req.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleSystem, Content: system},
+				{Role: openai.ChatMessageRoleUser, Content: prompt},
+			},
+			ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		})
+		if err != nil {
+			return cisco.Verification{}, err
+		}
+		c.usedTokens += resp.Usage.TotalTokens
+		if len(resp.Choices) > 0 {
+			answer = resp.Choices[0].Message.Content
+		}
+	} else {
+		model := c.gemini.GenerativeModel(c.client.Engine.Version)
+		model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(system)}}
+		model.ResponseMIMEType = "application/json"
+		resp, err := model.GenerateContent(c.ctx, genai.Text(prompt))
+		if err != nil {
+			return cisco.Verification{}, err
+		}
+		if resp.UsageMetadata != nil {
+			c.usedTokens += int(resp.UsageMetadata.TotalTokenCount)
+		}
+		if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+			for _, part := range resp.Candidates[0].Content.Parts {
+				if text, ok := part.(genai.Text); ok {
+					answer += string(text)
+				}
+			}
+		}
+	}
+
+	var verification cisco.Verification
+	answer = strings.TrimSpace(answer)
+	if err := json.Unmarshal([]byte(answer), &verification); err != nil {
+		return cisco.Verification{Verdict: "unknown", Summary: answer}, nil
+	}
+	switch verification.Verdict {
+	case "success", "partial", "failed":
+	default:
+		verification.Verdict = "unknown"
+	}
+	return verification, nil
+}
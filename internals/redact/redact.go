@@ -0,0 +1,177 @@
+// Package redact removes secrets from device data before it is sent to a
+// cloud LLM. Every secret is replaced by a placeholder such as <SECRET-1>.
+// The same value always gets the same placeholder, so the answers of the
+// model stay coherent over a conversation. The values are never put back:
+// a configuration that still contains a placeholder must not be applied.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Built-in IOS-XE patterns. Only the named groups are replaced and the
+// group name is the label of the placeholder.
+var builtin = []string{
+	// enable secret 9 $9$..., enable password level 15 7 0822455D0A16
+	`(?mi)^\s*enable (?:secret|password)(?: level \d+)?(?: \d)? (?P<secret>\S+)`,
+	// username admin privilege 15 secret 9 $9$...
+	`(?mi)^\s*username (?P<user>\S+)`,
+	`(?mi)^\s*username \S+ .*?\b(?:secret|password)(?: \d)? (?P<secret>\S+)`,
+	// line and PPP passwords
+	`(?mi)^\s*password(?: \d)? (?P<secret>\S+)`,
+	`(?mi)\bppp (?:chap|pap)(?: sent-username \S+)? password(?: \d)? (?P<secret>\S+)`,
+	`(?mi)^\s*neighbor \S+ password(?: \d)? (?P<secret>\S+)`,
+	// SNMP
+	`(?mi)^\s*snmp-server community (?P<community>\S+)`,
+	`(?mi)^\s*snmp-server host \S+(?: informs| traps)?(?: version (?:1|2c|3 (?:auth|noauth|priv)))? (?P<community>\S+)`,
+	`(?mi)^\s*snmp-server user (?P<user>\S+)`,
+	`(?mi)\bauth (?:md5|sha)\S* (?P<secret>\S+)`,
+	`(?mi)\bpriv (?:des|3des|aes(?: \d+)?) (?P<secret>\S+)`,
+	// TACACS+/RADIUS keys, also inside tacacs server / radius server blocks.
+	// A key must contain a letter so key chain IDs are kept.
+	`(?mi)^\s*(?:tacacs-server|radius-server)\b.*?\bkey(?: \d)? (?P<key>\S+)`,
+	`(?mi)^\s*server-private \S+ .*?\bkey(?: \d)? (?P<key>\S+)`,
+	`(?mi)^\s*(?:pac )?key(?: \d)? (?P<key>\S*[^\s0-9]\S*)\s*$`,
+	// Any key of a tacacs server / radius server block, digits only too
+	`(?mi)^(?:tacacs|radius) server \S+[ \t]*\r?\n(?:[ \t]+.*\n)*?[ \t]+(?:pac )?key(?: \d)? (?P<key>\S+)`,
+	`(?mi)\bkey-string(?: \d)? (?P<key>\S+)`,
+	`(?mi)\bip ospf authentication-key(?: \d)? (?P<key>\S+)`,
+	`(?mi)\bmessage-digest-key \d+ md5(?: \d)? (?P<key>\S+)`,
+	// ntp authentication-key 1 md5 104D000A0618 7
+	`(?mi)^\s*ntp authentication-key \d+ \S+ (?P<key>\S+)`,
+	// Pre-shared keys
+	`(?mi)^\s*crypto isakmp key(?: \d)? (?P<psk>\S+)`,
+	`(?mi)\bpre-shared-key(?: local| remote)?(?: \d)? (?P<psk>\S+)`,
+	`(?mi)\bwpa-psk ascii(?: \d)? (?P<psk>\S+)`,
+	// Credentials in packet payloads
+	`(?i)\b(?:password|passwd|pwd|pass)\s*[=:]\s*(?P<secret>[^\s&"']+)`,
+	`(?i)\bauthorization:\s*(?:basic|bearer)\s+(?P<secret>\S+)`,
+	`(?m)^\s*USER (?P<user>\S+)`,
+	`(?m)^\s*PASS (?P<secret>\S+)`,
+}
+
+var placeholder = regexp.MustCompile(`<[A-Z]+-[0-9]+>`)
+
+type Redactor struct {
+	rules  []*regexp.Regexp
+	custom []*regexp.Regexp
+
+	mu      sync.Mutex
+	values  map[string]string
+	counter map[string]int
+}
+
+func New() *Redactor {
+	r := &Redactor{values: map[string]string{}, counter: map[string]int{}}
+	for _, pattern := range builtin {
+		r.rules = append(r.rules, regexp.MustCompile(pattern))
+	}
+	return r
+}
+
+// Default is used for every payload sent to the LLM
+var Default = New()
+
+// SetPatterns replaces the user-defined regexes. When a regex has
+// capture groups only the groups are replaced, otherwise the whole match.
+func (r *Redactor) SetPatterns(patterns []string) error {
+	var custom []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %v", pattern, err)
+		}
+		custom = append(custom, re)
+	}
+	r.mu.Lock()
+	r.custom = custom
+	r.mu.Unlock()
+	return nil
+}
+
+// Text returns the text with every secret replaced by its placeholder
+func (r *Redactor) Text(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, re := range r.rules {
+		text = r.replace(re, text, "")
+	}
+	for _, re := range r.custom {
+		text = r.replace(re, text, "REDACTED")
+	}
+	return text
+}
+
+func (r *Redactor) replace(re *regexp.Regexp, text string, label string) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	names := re.SubexpNames()
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		groups := 0
+		for g := 1; g < len(names); g++ {
+			if m[2*g] < 0 {
+				continue
+			}
+			groups++
+			kind := label
+			if kind == "" {
+				if names[g] == "" {
+					continue
+				}
+				kind = strings.ToUpper(names[g])
+			}
+			out.WriteString(text[last:m[2*g]])
+			out.WriteString(r.placeholder(kind, text[m[2*g]:m[2*g+1]]))
+			last = m[2*g+1]
+		}
+		// User-defined regex without groups
+		if groups == 0 && label != "" {
+			out.WriteString(text[last:m[0]])
+			out.WriteString(r.placeholder(label, text[m[0]:m[1]]))
+			last = m[1]
+		}
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+func (r *Redactor) placeholder(kind string, value string) string {
+	if value == "" || placeholder.MatchString(value) {
+		return value
+	}
+	if p, ok := r.values[value]; ok {
+		return p
+	}
+	r.counter[kind]++
+	p := fmt.Sprintf("<%s-%d>", kind, r.counter[kind])
+	r.values[value] = p
+	return p
+}
+
+// Text redacts the text with the Default redactor
+func Text(text string) string {
+	return Default.Text(text)
+}
+
+// SetPatterns sets the user-defined regexes of the Default redactor
+func SetPatterns(patterns []string) error {
+	return Default.SetPatterns(patterns)
+}
+
+// Placeholders returns the lines of the text that contain a placeholder
+func Placeholders(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if placeholder.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
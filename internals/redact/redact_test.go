@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinPatterns(t *testing.T) {
+	tests := []struct {
+		kind   string
+		text   string
+		secret string
+		want   string
+	}{
+		{"enable secret", "enable secret 9 $9$nhEmQVczB7dqsO$X.HsgL6x1il0RxkOSSvyQYwucySCt7qFm4v7pqCxkKM",
+			"$9$nhEmQVczB7dqsO$X.HsgL6x1il0RxkOSSvyQYwucySCt7qFm4v7pqCxkKM", "enable secret 9 <SECRET-1>"},
+		{"enable password", "enable password level 15 7 0822455D0A16", "0822455D0A16", "enable password level 15 7 <SECRET-1>"},
+		{"username secret", "username admin privilege 15 secret 9 $9$abc", "$9$abc", "username <USER-1> privilege 15 secret 9 <SECRET-1>"},
+		{"line password", "line vty 0 4\n password 7 045802150C2E\n login", "045802150C2E", "line vty 0 4\n password 7 <SECRET-1>\n login"},
+		{"ppp password", " ppp chap password 0 Cisco123", "Cisco123", " ppp chap password 0 <SECRET-1>"},
+		{"bgp neighbor password", " neighbor 10.0.0.1 password 7 1511021F0725", "1511021F0725", " neighbor 10.0.0.1 password 7 <SECRET-1>"},
+		{"snmp community", "snmp-server community PubL1c RO", "PubL1c", "snmp-server community <COMMUNITY-1> RO"},
+		{"snmp host community", "snmp-server host 10.1.1.1 version 2c TrapC0mm", "TrapC0mm", "snmp-server host 10.1.1.1 version 2c <COMMUNITY-1>"},
+		{"snmpv3 user", "snmp-server user monitor GRP v3 auth sha AuthPass1 priv aes 128 PrivPass1",
+			"AuthPass1", "snmp-server user <USER-1> GRP v3 auth sha <SECRET-1> priv aes 128 <SECRET-2>"},
+		{"tacacs-server key", "tacacs-server host 10.1.1.5 key 7 0822455D0A16", "0822455D0A16", "tacacs-server host 10.1.1.5 key 7 <KEY-1>"},
+		{"server-private key", " server-private 10.1.1.5 key 0 S3cr3t", "S3cr3t", " server-private 10.1.1.5 key 0 <KEY-1>"},
+		{"tacacs server key", "tacacs server ISE\n address ipv4 10.1.1.5\n key 7 0822455D0A16", "0822455D0A16",
+			"tacacs server ISE\n address ipv4 10.1.1.5\n key 7 <KEY-1>"},
+		{"tacacs server digit key", "tacacs server ISE\n address ipv4 10.1.1.5\n key 0 123456\n!", "123456",
+			"tacacs server ISE\n address ipv4 10.1.1.5\n key 0 <KEY-1>\n!"},
+		{"radius server digit key", "radius server RAD1\n address ipv4 10.1.1.6 auth-port 1812 acct-port 1813\n key 987654", "987654",
+			"radius server RAD1\n address ipv4 10.1.1.6 auth-port 1812 acct-port 1813\n key <KEY-1>"},
+		{"radius server pac key", "radius server ISE\n address ipv4 10.1.1.7\n pac key 7 1234567", "1234567",
+			"radius server ISE\n address ipv4 10.1.1.7\n pac key 7 <KEY-1>"},
+		{"key-string", "key chain OSPF\n key 1\n  key-string 7 110A1016141D", "110A1016141D", "key chain OSPF\n key 1\n  key-string 7 <KEY-1>"},
+		{"ospf authentication-key", " ip ospf authentication-key 7 0822455D0A16", "0822455D0A16", " ip ospf authentication-key 7 <KEY-1>"},
+		{"ospf message-digest-key", " ip ospf message-digest-key 1 md5 7 0822455D0A16", "0822455D0A16", " ip ospf message-digest-key 1 md5 7 <KEY-1>"},
+		{"ntp authentication-key", "ntp authentication-key 1 md5 104D000A0618 7", "104D000A0618", "ntp authentication-key 1 md5 <KEY-1> 7"},
+		{"ntp authentication-key clear", "ntp authentication-key 2 md5 SECRET", "SECRET", "ntp authentication-key 2 md5 <KEY-1>"},
+		{"isakmp key", "crypto isakmp key 0 MyPsk123 address 10.2.2.2", "MyPsk123", "crypto isakmp key 0 <PSK-1> address 10.2.2.2"},
+		{"ikev2 pre-shared-key", "  pre-shared-key local 0 IkePsk!", "IkePsk!", "  pre-shared-key local 0 <PSK-1>"},
+		{"wlan psk", " security wpa psk set-key ascii 0 x wpa-psk ascii 0 WlanPsk1", "WlanPsk1", " security wpa psk set-key ascii 0 x wpa-psk ascii 0 <PSK-1>"},
+		{"payload password", "GET /login?user=bob&password=hunter2 HTTP/1.1", "hunter2", "GET /login?user=bob&password=<SECRET-1> HTTP/1.1"},
+		{"authorization header", "Authorization: Basic YWRtaW46c2VjcmV0", "YWRtaW46c2VjcmV0", "Authorization: Basic <SECRET-1>"},
+		{"ftp login", "USER ftpuser\nPASS ftppass", "ftppass", "USER <USER-1>\nPASS <SECRET-1>"},
+	}
+	for _, test := range tests {
+		got := New().Text(test.text)
+		if strings.Contains(got, test.secret) {
+			t.Errorf("%s: secret %q reaches the LLM: %q", test.kind, test.secret, got)
+		}
+		if got != test.want {
+			t.Errorf("%s: Text(%q) = %q, want %q", test.kind, test.text, got, test.want)
+		}
+	}
+}
+
+func TestKeyChainIDsAreKept(t *testing.T) {
+	text := "key chain EIGRP\n key 1\n  key-string 0 Ch41nK3y"
+	want := "key chain EIGRP\n key 1\n  key-string 0 <KEY-1>"
+	if got := New().Text(text); got != want {
+		t.Errorf("Text(%q) = %q, want %q", text, got, want)
+	}
+}
+
+func TestSamePlaceholder(t *testing.T) {
+	r := New()
+	first := r.Text("snmp-server community PubL1c RO")
+	second := r.Text("snmp-server community PubL1c RW\nsnmp-server community Pr1v RW")
+	if first != "snmp-server community <COMMUNITY-1> RO" {
+		t.Errorf("first = %q", first)
+	}
+	if second != "snmp-server community <COMMUNITY-1> RW\nsnmp-server community <COMMUNITY-2> RW" {
+		t.Errorf("second = %q", second)
+	}
+}
+
+func TestCustomPatterns(t *testing.T) {
+	r := New()
+	if err := r.SetPatterns([]string{`\bSITE-[0-9]+\b`, `wifi key (\S+)`}); err != nil {
+		t.Fatal(err)
+	}
+	got := r.Text("description SITE-1234 uplink\nwifi key Guest2024")
+	want := "description <REDACTED-1> uplink\nwifi key <REDACTED-2>"
+	if got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+	if err := r.SetPatterns([]string{`(`}); err == nil {
+		t.Error("SetPatterns accepted an invalid regex")
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	lines := Placeholders("interface Vlan10\n ip ospf authentication-key <KEY-1>\n shutdown")
+	if len(lines) != 1 || lines[0] != " ip ospf authentication-key <KEY-1>" {
+		t.Errorf("Placeholders = %q", lines)
+	}
+}
@@ -0,0 +1,64 @@
+package internals
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+)
+
+// Rollback reverts an applied change, confirms a pending one or lists them.
+// Usage: aixedge-rollback <change-id> | --confirm <change-id> | --list
+func (c *Client) Rollback(args []string) {
+	flags := flag.NewFlagSet("aixedge-rollback", flag.ContinueOnError)
+	confirmID := flags.String("confirm", "", "keep a change applied from the assistant")
+	list := flags.Bool("list", false, "list the changes that can be rolled back")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	switch {
+	case *list:
+		ids := cisco.Archives()
+		if len(ids) == 0 {
+			fmt.Println("No change archived")
+			return
+		}
+		for _, id := range ids {
+			fmt.Printf("%s\t%s\n", id, cisco.ChangeState(id))
+		}
+	case *confirmID != "":
+		if err := cisco.ConfirmChange(*confirmID); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Change %s confirmed\n", *confirmID)
+	case flags.NArg() == 1:
+		id := flags.Arg(0)
+		fmt.Printf("The running-config is replaced by the one saved before change %s, later changes are reverted too.\n", id)
+		fmt.Println("Do you want to continue? (yes/no)")
+		var answer string
+		fmt.Scanln(&answer)
+		if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+			fmt.Println("Rollback cancelled")
+			return
+		}
+		if err := cisco.Rollback(id); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Change %s rolled back\n", id)
+	default:
+		fmt.Println("Usage: aixedge-rollback <change-id> | --confirm <change-id> | --list")
+	}
+}
+
+// RollbackTimer is run in the background by ReviewConfig after a change is applied
+func (c *Client) RollbackTimer(id string, minutes string) {
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m <= 0 {
+		m = cisco.ConfirmMinutes
+	}
+	cisco.RollbackTimer(id, m)
+}
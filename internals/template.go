@@ -0,0 +1,119 @@
+package internals
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Cisco-AIXEdge/Cisco-AIXEdge/internals/cisco"
+)
+
+// templateVars collects the repeated --var name=value arguments
+type templateVars map[string]string
+
+func (v templateVars) String() string {
+	var pairs []string
+	for name, value := range v {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func (v templateVars) Set(pair string) error {
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected name=value, got %s", pair)
+	}
+	v[strings.TrimSpace(name)] = value
+	return nil
+}
+
+// Template lists, shows, deletes and applies the configuration templates saved from the chat.
+// Usage: aixedge-template list | show <name> | delete <name> | apply <name> --var name=value ...
+func (c *Client) Template(args []string) {
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch {
+	case command == "list":
+		templates := cisco.Templates()
+		if len(templates) == 0 {
+			fmt.Println("No template saved. Ask the assistant in aixedge-chat to save one.")
+			return
+		}
+		for _, t := range templates {
+			fmt.Printf("%-20s\t%s\n", t.Name, t.Description)
+		}
+	case command == "show" && len(args) == 2:
+		t, err := cisco.LoadTemplate(args[1])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%s: %s\n\nVariables:\n", t.Name, t.Description)
+		var names []string
+		for name := range t.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-12s %s\n", name, t.Variables[name])
+		}
+		fmt.Println("\nTemplate:")
+		fmt.Println(cisco.Green + t.Body + cisco.Reset)
+		fmt.Println("\nApply it with: " + cisco.TemplateUsage(t))
+	case command == "delete" && len(args) == 2:
+		if err := cisco.DeleteTemplate(args[1]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Template %s deleted\n", args[1])
+	case command == "apply" && len(args) >= 2:
+		c.applyTemplate(args[1], args[2:])
+	default:
+		fmt.Println("Usage: aixedge-template list | show <name> | delete <name> | apply <name> --var name=value ...")
+	}
+}
+
+// Function renders the template and hands it to the same review as the chat:
+// edit, diff, lint, risk, confirmation, apply and verification
+func (c *Client) applyTemplate(name string, args []string) {
+	vars := templateVars{}
+	flags := flag.NewFlagSet("aixedge-template apply", flag.ContinueOnError)
+	flags.Var(vars, "var", "value of a template variable, name=value, repeated for each variable")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	t, err := cisco.LoadTemplate(name)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	config, err := t.Render(vars)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: " + cisco.TemplateUsage(t))
+		return
+	}
+
+	cfg, err := c.configRead()
+	if err != nil {
+		fmt.Println("AIXEdge is not configured. Please do copilot-cfg <LLM Provider> <Model> <API KEY>")
+		return
+	}
+	cisco.Change.Device = cfg.device()
+	cisco.Change.Question = fmt.Sprintf("template %s %s", name, vars)
+	cisco.StructuredBackend, err = cfg.Backend.backend()
+	if err != nil {
+		fmt.Println(err)
+	}
+	closePrompt := openPrompt()
+	defer closePrompt()
+
+	fmt.Printf("Template %s rendered:\n", name)
+	cisco.CodeBlocks = []string{config}
+	fmt.Println(cisco.ReviewConfig())
+}
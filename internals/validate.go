@@ -24,7 +24,7 @@ func validateModel(provider string, model string, apiKey string) (bool, error) {
 	switch provider {
 	case "openai":
 		client := openai.NewClient(apiKey)
-		model, err := client.GetModel(ctx, model)
+		_, err := client.GetModel(ctx, model)
 		if err != nil {
 			return false, fmt.Errorf("Invalid model '%s'\nRefer to https://platform.openai.com/docs/models for available models\n", model)
 		}
@@ -46,5 +46,4 @@ func validateModel(provider string, model string, apiKey string) (bool, error) {
 	default:
 		return false, fmt.Errorf("Unsupported provider: %s", provider)
 	}
-	return false, fmt.Errorf("Unsupported provider: %s", provider)
 }